@@ -0,0 +1,237 @@
+package go_pool
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// batch Items collected by a BatchPool, runs as a single task of the underlying pool
+type batch[T any] struct {
+	items   []T
+	futures []*Future[struct{}]
+	started []int   // Indexes of items handed to the batch callback, cancelled items are left out
+	errs    []error // Per item outcome reported by the batch callback, indexed like started
+}
+
+// BatchPool Goroutine pool collecting submitted items into batches, a batch runs once it holds batch size
+// items or the flush interval has elapsed since its first item. Every item gets a future with its own outcome.
+// Pool options apply to batches: queue size and rate limit count batches, so do stats
+type BatchPool[T interface{}] struct {
+	pool    *Pool[*batch[T]]
+	options options[T]
+
+	mu      sync.Mutex  // Guards the fields below
+	pending *batch[T]   // Batch being collected, nil if no item is waiting
+	timer   *time.Timer // Runs pending once the flush interval elapses
+	closed  bool        // Exit has been called
+}
+
+func NewBatchPool[T interface{}](opts ...Option[T]) *BatchPool[T] {
+	p := BatchPool[T]{}
+	for _, opt := range opts {
+		opt.apply(&p.options)
+	}
+	if p.options.batchSize <= 0 {
+		log.Fatal("batch size is less then or equal 0")
+	}
+	if p.options.batchCB == nil && p.options.batchErrCB == nil {
+		log.Fatal("param batchCB is nil")
+	}
+
+	o := p.options
+	batchOpts := options[*batch[T]]{
+		size:          o.size,
+		minSize:       o.minSize,
+		maxSize:       o.maxSize,
+		idleTimeout:   o.idleTimeout,
+		queueSize:     o.queueSize,
+		aging:         o.aging,
+		taskCB:        p.runBatch,
+		taskTimeout:   o.taskTimeout,
+		doneErrCB:     p.finishBatch,
+		debug:         o.debug,
+		statsInterval: o.statsInterval,
+		statsCB:       o.statsCB,
+		rateLimit:     o.rateLimit,
+		rateBurst:     o.rateBurst,
+		workerBase:    o.workerBase,
+	}
+	if o.panicCB != nil {
+		// Reported with the first item, the batch itself is not visible to callers
+		batchOpts.panicCB = func(b *batch[T], worker int, recovered any, stack []byte) {
+			var t T
+			if len(b.items) > 0 {
+				t = b.items[0]
+			}
+			o.panicCB(t, worker, recovered, stack)
+		}
+	}
+	p.pool = NewPool[*batch[T]](optionsOption[*batch[T]](batchOpts))
+	return &p
+}
+
+// Submit Add t to the pending batch and return the future of its outcome. The submitter filling up
+// a batch waits for queue space until ctx is done, a submission failure is reported through the futures
+// of the whole batch
+func (p *BatchPool[T]) Submit(ctx context.Context, t T) *Future[struct{}] {
+	f := newFuture[struct{}](func() {})
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		f.complete(struct{}{}, ErrPoolClosed)
+		return f
+	}
+	if p.pending == nil {
+		b := &batch[T]{}
+		p.pending = b
+		if p.options.flushInterval > 0 {
+			p.timer = time.AfterFunc(p.options.flushInterval, func() {
+				p.flushBatch(b)
+			})
+		}
+	}
+	p.pending.items = append(p.pending.items, t)
+	p.pending.futures = append(p.pending.futures, f)
+	var full *batch[T]
+	if len(p.pending.items) >= p.options.batchSize {
+		full = p.takeLocked()
+	}
+	p.mu.Unlock()
+
+	if full != nil {
+		p.submitBatch(ctx, full)
+	}
+	return f
+}
+
+// Flush Run the pending batch now without waiting for it to fill up
+func (p *BatchPool[T]) Flush(ctx context.Context) {
+	p.mu.Lock()
+	b := p.takeLocked()
+	p.mu.Unlock()
+	p.submitBatch(ctx, b)
+}
+
+// takeLocked Detach the pending batch, p.mu must be held
+func (p *BatchPool[T]) takeLocked() *batch[T] {
+	b := p.pending
+	p.pending = nil
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	return b
+}
+
+// flushBatch Run b on flush interval, unless it has already been taken
+func (p *BatchPool[T]) flushBatch(b *batch[T]) {
+	p.mu.Lock()
+	if p.pending != b {
+		p.mu.Unlock()
+		return
+	}
+	p.takeLocked()
+	p.mu.Unlock()
+	p.submitBatch(context.Background(), b)
+}
+
+func (p *BatchPool[T]) submitBatch(ctx context.Context, b *batch[T]) {
+	if b == nil {
+		return
+	}
+	if err := p.pool.Submit(ctx, b); err != nil {
+		for _, f := range b.futures {
+			f.complete(struct{}{}, err)
+		}
+	}
+}
+
+// runBatch Hand the items not cancelled yet to the batch callback
+func (p *BatchPool[T]) runBatch(b *batch[T], worker int) {
+	items := make([]T, 0, len(b.items))
+	for k, f := range b.futures {
+		if f.state.CompareAndSwap(futurePending, futureRunning) {
+			b.started = append(b.started, k)
+			items = append(items, b.items[k])
+		}
+	}
+	if len(items) == 0 {
+		return
+	}
+	if p.options.batchErrCB != nil {
+		b.errs = p.options.batchErrCB(items, worker)
+		return
+	}
+	p.options.batchCB(items, worker)
+}
+
+// finishBatch Complete item futures with their outcome and call done callbacks per item
+func (p *BatchPool[T]) finishBatch(b *batch[T], worker int, err error) {
+	errs := make([]error, len(b.items))
+	if err != nil {
+		// Batch panicked or timed out, b.started may still be written by the callback
+		for k := range errs {
+			errs[k] = err
+		}
+	} else {
+		for k, idx := range b.started {
+			if k < len(b.errs) {
+				errs[idx] = b.errs[k]
+			}
+		}
+	}
+
+	for k, f := range b.futures {
+		f.complete(struct{}{}, errs[k])
+	}
+	for k, f := range b.futures {
+		if f.state.Load() == futureCancelled {
+			continue
+		}
+		t, itemErr := b.items[k], errs[k]
+		p.pool.safeCall(b, worker, func(*batch[T], int) {
+			if p.options.doneCB != nil {
+				p.options.doneCB(t, worker)
+			}
+			if p.options.doneErrCB != nil {
+				p.options.doneErrCB(t, worker, itemErr)
+			}
+		})
+	}
+}
+
+// Exit Run the pending batch and stop accepting items, queued batches are still finished.
+// Only waits for queue space of the pending batch
+func (p *BatchPool[T]) Exit() {
+	p.mu.Lock()
+	p.closed = true
+	b := p.takeLocked()
+	p.mu.Unlock()
+
+	p.submitBatch(context.Background(), b)
+	p.pool.Exit()
+}
+
+// Shutdown Run the pending batch, stop accepting items and wait until all batches are finished or ctx is done
+func (p *BatchPool[T]) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	b := p.takeLocked()
+	p.mu.Unlock()
+
+	p.submitBatch(ctx, b)
+	return p.pool.Shutdown(ctx)
+}
+
+// Stats Snapshot of pool state, task counters count batches
+func (p *BatchPool[T]) Stats() Stats {
+	return p.pool.Stats()
+}
+
+// Panics Number of panics recovered in batch and done callbacks
+func (p *BatchPool[T]) Panics() int64 {
+	return p.pool.Panics()
+}
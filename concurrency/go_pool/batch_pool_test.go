@@ -0,0 +1,79 @@
+package go_pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchPool(t *testing.T) {
+	assert := require.New(t)
+	errOdd := errors.New("odd item")
+	mu := sync.Mutex{}
+	var batches [][]int
+	p := NewBatchPool(
+		WithSize[int](1),
+		WithBatchSize[int](3),
+		WithFlushInterval[int](20*time.Millisecond),
+		WithBatchErrCB(func(items []int, worker int) []error {
+			mu.Lock()
+			batches = append(batches, items)
+			mu.Unlock()
+			errs := make([]error, len(items))
+			for k, item := range items {
+				if item%2 == 1 {
+					errs[k] = errOdd
+				}
+			}
+			return errs
+		}),
+	)
+
+	ctx := context.Background()
+	futures := make([]*Future[struct{}], 7)
+	for i := range futures {
+		futures[i] = p.Submit(ctx, i)
+	}
+	// Last item runs alone once the flush interval elapses
+	_, err := futures[6].Get(ctx)
+	assert.NoError(err)
+	for i, f := range futures {
+		_, err := f.Get(ctx)
+		if i%2 == 1 {
+			assert.ErrorIs(err, errOdd)
+		} else {
+			assert.NoError(err)
+		}
+	}
+	assert.NoError(p.Shutdown(ctx))
+	assert.Equal([][]int{{0, 1, 2}, {3, 4, 5}, {6}}, batches)
+	assert.Equal(int64(3), p.Stats().Completed)
+
+	_, err = p.Submit(ctx, 7).Get(ctx)
+	assert.ErrorIs(err, ErrPoolClosed)
+}
+
+func TestBatchPoolPanic(t *testing.T) {
+	assert := require.New(t)
+	p := NewBatchPool(
+		WithSize[int](1),
+		WithBatchSize[int](2),
+		WithBatchCB(func(items []int, worker int) {
+			panic("batch failed")
+		}),
+		WithPanicHandler(func(t int, worker int, recovered any, stack []byte) {}),
+	)
+	ctx := context.Background()
+	f0 := p.Submit(ctx, 0)
+	f1 := p.Submit(ctx, 1)
+	_, err := WaitAll(ctx, f0, f1)
+	assert.ErrorIs(err, ErrTaskPanic)
+	_, err = f1.Get(ctx)
+	assert.ErrorIs(err, ErrTaskPanic)
+	assert.NoError(p.Shutdown(ctx))
+	assert.Equal(int64(1), p.Panics())
+}
@@ -0,0 +1,145 @@
+package go_pool
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"go.uber.org/atomic"
+)
+
+const (
+	futurePending int32 = iota
+	futureRunning
+	futureCancelled
+)
+
+// Future Result of a function submitted with SubmitFunc, can be called concurrently
+type Future[R any] struct {
+	state  atomic.Int32
+	once   sync.Once
+	doneCh chan struct{}
+	result R
+	err    error
+	cancel context.CancelFunc
+}
+
+func newFuture[R any](cancel context.CancelFunc) *Future[R] {
+	return &Future[R]{
+		doneCh: make(chan struct{}),
+		cancel: cancel,
+	}
+}
+
+func (f *Future[R]) complete(result R, err error) {
+	f.once.Do(func() {
+		f.result, f.err = result, err
+		f.cancel()
+		close(f.doneCh)
+	})
+}
+
+// Get Wait for the function result until ctx is done
+func (f *Future[R]) Get(ctx context.Context) (result R, err error) {
+	select {
+	case <-f.doneCh:
+		return f.result, f.err
+	case <-ctx.Done():
+		return result, ctx.Err()
+	}
+}
+
+// Done Closed when the result is available
+func (f *Future[R]) Done() <-chan struct{} {
+	return f.doneCh
+}
+
+// Cancel Cancel the context passed to the function. A function not yet started is skipped
+// and its future completes with context.Canceled right away
+func (f *Future[R]) Cancel() {
+	f.cancel()
+	if f.state.CompareAndSwap(futurePending, futureCancelled) {
+		var result R
+		f.complete(result, context.Canceled)
+	}
+}
+
+// SubmitFunc Run fn in pool p with normal priority and return its future, fn gets a context derived
+// from ctx that is cancelled by Future.Cancel. A submission failure is reported through the future
+func SubmitFunc[R any, F ~func()](ctx context.Context, p *Pool[F], fn func(ctx context.Context) (R, error)) *Future[R] {
+	return SubmitFuncWithPriority(ctx, p, PriorityNormal, fn)
+}
+
+// SubmitFuncWithPriority Same as SubmitFunc, higher priority functions are served first
+func SubmitFuncWithPriority[R any, F ~func()](ctx context.Context, p *Pool[F], priority Priority,
+	fn func(ctx context.Context) (R, error)) *Future[R] {
+	taskCtx, cancel := context.WithCancel(ctx)
+	f := newFuture[R](cancel)
+
+	err := p.SubmitWithPriority(ctx, F(func() {
+		if !f.state.CompareAndSwap(futurePending, futureRunning) {
+			// Cancelled before started
+			return
+		}
+		var result R
+		if err := taskCtx.Err(); err != nil {
+			f.complete(result, err)
+			return
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				f.complete(result, fmt.Errorf("%w: %v", ErrTaskPanic, r))
+				panic(r)
+			}
+		}()
+		result, err := fn(taskCtx)
+		f.complete(result, err)
+	}), priority)
+	if err != nil {
+		var result R
+		f.complete(result, err)
+	}
+	return f
+}
+
+// NewFuncPool Create a pool running submitted functions, to be used with SubmitFunc
+func NewFuncPool(opts ...Option[func()]) *Pool[func()] {
+	opts = append(opts, WithTaskCB(func(fn func(), i int) {
+		fn()
+	}))
+	return NewPool(opts...)
+}
+
+// WaitAll Wait for all futures until ctx is done, results keep the order of fs.
+// err is the first error in the order of fs
+func WaitAll[R any](ctx context.Context, fs ...*Future[R]) (results []R, err error) {
+	results = make([]R, len(fs))
+	for i, f := range fs {
+		var fErr error
+		results[i], fErr = f.Get(ctx)
+		if fErr != nil && err == nil {
+			err = fErr
+		}
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+	}
+	return results, err
+}
+
+// WaitAny Wait for the first completed future until ctx is done, returns its index in fs and result
+func WaitAny[R any](ctx context.Context, fs ...*Future[R]) (index int, result R, err error) {
+	cases := make([]reflect.SelectCase, 0, len(fs)+1)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+	for _, f := range fs {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(f.doneCh)})
+	}
+
+	chosen, _, _ := reflect.Select(cases)
+	if chosen == 0 {
+		return -1, result, ctx.Err()
+	}
+	index = chosen - 1
+	return index, fs[index].result, fs[index].err
+}
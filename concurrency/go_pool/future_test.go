@@ -0,0 +1,95 @@
+package go_pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitFunc(t *testing.T) {
+	assert := require.New(t)
+	p := NewFuncPool(
+		WithSize[func()](4),
+		WithPanicHandler(func(fn func(), worker int, recovered any, stack []byte) {}),
+	)
+	ctx := context.Background()
+
+	var fs []*Future[int]
+	for i := 0; i < 10; i++ {
+		i := i
+		fs = append(fs, SubmitFunc(ctx, p, func(ctx context.Context) (int, error) {
+			return i * i, nil
+		}))
+	}
+	results, err := WaitAll(ctx, fs...)
+	assert.NoError(err)
+	for i := range results {
+		assert.Equal(i*i, results[i])
+	}
+
+	errFail := errors.New("fail")
+	f := SubmitFunc(ctx, p, func(ctx context.Context) (string, error) {
+		return "", errFail
+	})
+	_, err = f.Get(ctx)
+	assert.ErrorIs(err, errFail)
+
+	f = SubmitFunc(ctx, p, func(ctx context.Context) (string, error) {
+		panic("boom")
+	})
+	_, err = f.Get(ctx)
+	assert.ErrorIs(err, ErrTaskPanic)
+
+	assert.NoError(p.Shutdown(ctx))
+	f = SubmitFunc(ctx, p, func(ctx context.Context) (string, error) {
+		return "", nil
+	})
+	_, err = f.Get(ctx)
+	assert.ErrorIs(err, ErrPoolClosed)
+}
+
+func TestFutureCancel(t *testing.T) {
+	assert := require.New(t)
+	p := NewFuncPool(WithSize[func()](1), WithQueueSize[func()](4))
+	ctx := context.Background()
+
+	// Running function observes cancellation through its context
+	started := make(chan struct{})
+	running := SubmitFunc(ctx, p, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	// Queued function never runs after cancellation
+	queued := SubmitFunc(ctx, p, func(ctx context.Context) (int, error) {
+		assert.Fail("cancelled function must not run")
+		return 0, nil
+	})
+	<-started
+	queued.Cancel()
+	_, err := queued.Get(ctx)
+	assert.ErrorIs(err, context.Canceled)
+
+	slow := SubmitFunc(ctx, p, func(ctx context.Context) (int, error) {
+		return 2, nil
+	})
+	getCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	index, _, err := WaitAny(getCtx, running, slow)
+	assert.Equal(-1, index)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+
+	running.Cancel()
+	index, _, err = WaitAny(ctx, running)
+	assert.Equal(0, index)
+	assert.ErrorIs(err, context.Canceled)
+
+	index, result, err := WaitAny(ctx, slow)
+	assert.Equal(0, index)
+	assert.Equal(2, result)
+	assert.NoError(err)
+	assert.NoError(p.Shutdown(ctx))
+}
@@ -1,7 +1,11 @@
 package go_pool
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"go.uber.org/atomic"
@@ -9,79 +13,363 @@ import (
 
 // Implementation of goroutine pool to improve event processing concurrency
 
+var (
+	// ErrPoolClosed Pool has exited and no longer accepts tasks
+	ErrPoolClosed = errors.New("go_pool: pool is closed")
+	// ErrPoolFull Task queue reached its capacity
+	ErrPoolFull = errors.New("go_pool: pool is full")
+	// ErrRateLimited Task was not admitted by the pool rate limit
+	ErrRateLimited = errors.New("go_pool: rate limited")
+	// ErrTaskPanic Task callback panicked
+	ErrTaskPanic = errors.New("go_pool: task panicked")
+	// ErrTaskTimeout Task callback did not return within the task timeout
+	ErrTaskTimeout = errors.New("go_pool: task timed out")
+)
+
 type Pool[T interface{}] struct {
-	running atomic.Int32  // Number of running goroutines
-	taskCh  chan T        // Event channel
-	exitCh  chan struct{} // Channel for closing goroutine pool
-	options options[T]    // Configuration
+	running      atomic.Int32    // Number of running goroutines
+	panics       atomic.Int64    // Number of panics recovered in callbacks
+	submitted    atomic.Int64    // Number of tasks accepted
+	completed    atomic.Int64    // Number of tasks finished
+	failed       atomic.Int64    // Number of tasks whose task callback failed, panicked or timed out
+	retried      atomic.Int64    // Number of task runs repeated by the retry policy
+	latency      latencyRecorder // Task callback durations
+	queueWait    latencyRecorder // Time tasks spent in the queue
+	throttled    atomic.Int64    // Number of submissions delayed or rejected by the rate limit
+	throttleWait latencyRecorder // Time submissions waited for the rate limit
+	limiter      *tokenBucket    // Rate limit on task admission, nil if unlimited
+	queue        *queue[T]       // Pending tasks
+	options      options[T]      // Configuration
+
+	ctx    context.Context    // Parent of task contexts
+	cancel context.CancelFunc // Cancels running tasks when Shutdown gives up waiting
+
+	mu      sync.Mutex    // Guards the worker bookkeeping below
+	workers int           // Number of live workers
+	idle    int           // Number of workers waiting for tasks
+	freeIDs []int         // Worker indexes not in use, keeps indexes within [workerBase, workerBase+maxSize)
+	closed  bool          // Exit has been called
+	doneCh  chan struct{} // Closed once the pool is closed and all workers have exited
 }
 
 func NewPool[T interface{}](opts ...Option[T]) *Pool[T] {
 	p := Pool[T]{
-		taskCh:  make(chan T),
-		exitCh:  make(chan struct{}),
 		options: options[T]{},
+		doneCh:  make(chan struct{}),
 	}
 	for _, opt := range opts {
 		opt.apply(&p.options)
 	}
 
-	if p.options.size <= 0 {
+	if shared := p.options.shared; shared != nil {
+		// Workers are owned by the pool set, max size only caps the workers serving this pool
+		if p.options.maxSize <= 0 || p.options.maxSize > shared.size {
+			p.options.maxSize = shared.size
+		}
+		p.options.size, p.options.minSize = 0, 0
+		if p.options.weight <= 0 {
+			p.options.weight = 1
+		}
+	}
+	if p.options.maxSize <= 0 {
+		// Fixed size pool
+		p.options.maxSize = p.options.size
+	}
+	if p.options.minSize <= 0 {
+		p.options.minSize = p.options.size
+	}
+	if p.options.maxSize <= 0 {
 		log.Fatal("size is less then or equal 0")
 	}
-	if p.options.taskCB == nil {
+	if p.options.minSize > p.options.maxSize {
+		log.Fatal("min size is greater than max size")
+	}
+	if p.options.taskCB == nil && p.options.taskCtxCB == nil && p.options.taskErrCB == nil {
 		log.Fatal("param taskCB is nil")
 	}
-	for i := 0; i < p.options.size; i++ {
-		go func(i int) {
-			p.startWorker(i)
-		}(i)
+	if p.options.queueSize <= 0 {
+		// Default queue holds one pending task per worker
+		p.options.queueSize = p.options.maxSize
+	}
+	if p.options.idleTimeout <= 0 {
+		p.options.idleTimeout = 3 * time.Minute
+	}
+	if p.options.aging <= 0 {
+		p.options.aging = time.Second
+	}
+	if p.options.retryable == nil {
+		p.options.retryable = defaultRetryable
+	}
+	p.queue = newQueue[T](p.options.queueSize, p.options.aging)
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	if p.options.limiter != nil {
+		p.limiter = p.options.limiter
+	} else if p.options.rateLimit > 0 {
+		p.limiter = newTokenBucket(p.options.rateLimit, p.options.rateBurst)
+	}
+	for i := p.options.maxSize - 1; i >= 0; i-- {
+		p.freeIDs = append(p.freeIDs, p.options.workerBase+i)
+	}
+
+	p.mu.Lock()
+	for i := 0; i < p.options.minSize; i++ {
+		p.spawnLocked()
+	}
+	p.mu.Unlock()
+	if p.options.statsCB != nil && p.options.statsInterval > 0 {
+		go p.reportStats()
 	}
 	return &p
 }
 
+// spawnLocked Start a new worker, p.mu must be held
+func (p *Pool[T]) spawnLocked() {
+	i := p.freeIDs[len(p.freeIDs)-1]
+	p.freeIDs = p.freeIDs[:len(p.freeIDs)-1]
+	p.workers++
+	go p.startWorker(i)
+}
+
+// retireLocked Bookkeeping for an exiting worker, p.mu must be held
+func (p *Pool[T]) retireLocked(i int) {
+	p.freeIDs = append(p.freeIDs, i)
+	p.workers--
+	p.drainedLocked()
+}
+
+// drainedLocked Close doneCh once the pool is closed and has no workers left, p.mu must be held.
+// Shared workers come and go per task, so a shared pool also needs its queue drained
+func (p *Pool[T]) drainedLocked() {
+	if !p.closed || p.workers > 0 {
+		return
+	}
+	if p.options.shared != nil && p.queue.len() > 0 {
+		return
+	}
+	select {
+	case <-p.doneCh:
+	default:
+		close(p.doneCh)
+	}
+}
+
+// grow Start one more worker if queued tasks outnumber idle workers and max size is not reached.
+// A paused pool does not grow, its workers would only idle
+func (p *Pool[T]) grow() {
+	if p.options.shared != nil {
+		p.options.shared.wake()
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || p.workers >= p.options.maxSize || p.queue.isPaused() {
+		return
+	}
+	if p.queue.len() > p.idle {
+		p.spawnLocked()
+	}
+}
+
 func (p *Pool[T]) startWorker(i int) {
 	if p.options.debug {
 		log.Println("go_pool start workder ", i)
 	}
+	idleTimer := time.NewTimer(p.options.idleTimeout)
+	defer idleTimer.Stop()
 	for {
-		select {
-		case _, ok := <-p.exitCh:
-			if !ok {
+		item, ok, wait := p.queue.pop()
+		if !ok {
+			if wait == nil {
+				// Queue closed and drained
 				if p.options.debug {
 					log.Println("go_pool exit worker ", i)
 				}
+				p.mu.Lock()
+				p.retireLocked(i)
+				p.mu.Unlock()
 				return
 			}
-
-		case t, ok := <-p.taskCh:
-			if !ok {
-				continue
-			}
-			p.running.Add(1)
-			{
-				p.options.taskCB(t, i)
-				if p.options.doneCB != nil {
-					p.options.doneCB(t, i)
-				}
+			if p.waitTask(i, wait, idleTimer) {
+				return
 			}
-			p.running.Add(-1)
-		case <-time.After(3 * time.Minute):
-    		if p.options.debug {
-       			 log.Println("go_pool worker ", i, " timed out")
-    		}
-    	}
+			continue
+		}
+
+		p.running.Add(1)
+		p.queueWait.record(time.Since(item.enqueuedAt))
+		p.execute(item.t, i)
+		p.running.Add(-1)
+	}
+}
+
+// waitTask Idle until wait is closed, returns true if the worker has been retired after idle timeout
+func (p *Pool[T]) waitTask(i int, wait <-chan struct{}, idleTimer *time.Timer) (retired bool) {
+	p.mu.Lock()
+	p.idle++
+	p.mu.Unlock()
+
+	if !idleTimer.Stop() {
+		select {
+		case <-idleTimer.C:
+		default:
+		}
+	}
+	idleTimer.Reset(p.options.idleTimeout)
+
+	timeout := false
+	select {
+	case <-wait:
+	case <-idleTimer.C:
+		timeout = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle--
+	if timeout && p.workers > p.options.minSize && p.queue.len() == 0 {
+		if p.options.debug {
+			log.Println("go_pool retire idle worker ", i)
+		}
+		p.retireLocked(i)
+		return true
+	}
+	return false
+}
+
+// New Put task into the pool, blocks until the task is queued
+func (p *Pool[T]) New(t T) error {
+	return p.Submit(context.Background(), t)
+}
+
+// Submit Put task into the pool with normal priority, waits for rate limit admission and free queue space
+// until ctx is done. Returns ErrPoolClosed after Exit, or ErrRateLimited/ErrPoolFull wrapping ctx error
+// when the wait is given up
+func (p *Pool[T]) Submit(ctx context.Context, t T) error {
+	return p.SubmitWithPriority(ctx, t, PriorityNormal)
+}
+
+// SubmitWithPriority Same as Submit, higher priority tasks are served first
+func (p *Pool[T]) SubmitWithPriority(ctx context.Context, t T, priority Priority) error {
+	if p.limiter != nil {
+		waited, err := p.limiter.wait(ctx)
+		if waited > 0 {
+			p.throttled.Add(1)
+			p.throttleWait.record(waited)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrRateLimited, err)
+		}
+	}
+	err := p.queue.push(ctx, t, priority)
+	if err != nil {
+		if p.limiter != nil {
+			p.limiter.refund()
+		}
+		return err
 	}
+	p.submitted.Add(1)
+	p.grow()
+	return nil
 }
 
-func (p *Pool[T]) New(t T) {
-	p.taskCh <- t
+// TrySubmit Put task into the pool with normal priority without waiting, returns false if the pool is full,
+// closed or out of rate limit tokens
+func (p *Pool[T]) TrySubmit(t T) bool {
+	return p.TrySubmitWithPriority(t, PriorityNormal)
+}
+
+// TrySubmitWithPriority Same as TrySubmit, higher priority tasks are served first
+func (p *Pool[T]) TrySubmitWithPriority(t T, priority Priority) bool {
+	return p.trySubmit(t, priority) == nil
+}
+
+// trySubmit Put task into the pool without waiting, fails with ErrRateLimited, ErrPoolFull or ErrPoolClosed
+func (p *Pool[T]) trySubmit(t T, priority Priority) error {
+	if p.limiter != nil && !p.limiter.allow() {
+		p.throttled.Add(1)
+		return ErrRateLimited
+	}
+	err := p.queue.tryPush(t, priority)
+	if err != nil {
+		if p.limiter != nil {
+			p.limiter.refund()
+		}
+		return err
+	}
+	p.submitted.Add(1)
+	p.grow()
+	return nil
 }
 
+// Exit Stop accepting tasks, workers exit after running and queued tasks are finished. Does not wait
 func (p *Pool[T]) Exit() {
-	close(p.exitCh)
+	p.queue.close()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	p.drainedLocked()
+}
+
+// Shutdown Stop accepting tasks and wait until running and queued tasks are finished and all workers
+// have exited. Returns ctx error if ctx is done first, task contexts are then cancelled
+// and the remaining tasks keep draining in background
+func (p *Pool[T]) Shutdown(ctx context.Context) error {
+	p.Exit()
+
+	select {
+	case <-p.doneCh:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+// Pause Stop starting tasks, running tasks are finished and workers then idle. Submissions are still
+// queued up to queue capacity. Shutdown of a paused pool waits for Resume or ctx to be done
+func (p *Pool[T]) Pause() {
+	p.queue.pause()
+}
+
+// Resume Start running queued tasks again, workers are added up to max size for the queued tasks
+func (p *Pool[T]) Resume() {
+	p.queue.resume()
+	if p.options.shared != nil {
+		p.options.shared.wake()
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for spawned := 0; !p.closed && p.workers < p.options.maxSize && p.queue.len() > p.idle+spawned; spawned++ {
+		p.spawnLocked()
+	}
+}
+
+// Paused Whether the pool has been paused and not resumed since
+func (p *Pool[T]) Paused() bool {
+	return p.queue.isPaused()
 }
 
 func (p *Pool[T]) IsFull() bool {
-	return p.options.size <= int(p.running.Load())
+	return p.options.maxSize <= int(p.running.Load())
+}
+
+// Workers Number of live workers, varies between min and max size
+func (p *Pool[T]) Workers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.workers
+}
+
+// Panics Number of panics recovered from task and done callbacks
+func (p *Pool[T]) Panics() int64 {
+	return p.panics.Load()
 }
@@ -1,10 +1,26 @@
 package go_pool
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
 
-// Contains multiple goroutine pools, each with a unique identifier
+var (
+	// ErrPoolNotFound No pool registered under the given name
+	ErrPoolNotFound = errors.New("go_pool: pool not found")
+	// ErrPoolExists A pool is already registered under the given name
+	ErrPoolExists = errors.New("go_pool: pool already exists")
+)
+
+// Contains multiple goroutine pools, each with a unique identifier. Pools can be added and removed
+// at runtime, can be called concurrently
 type PoolSet[T interface{}] struct {
+	mu         sync.RWMutex
 	nameToPool map[string]*Pool[T]
+	closed     bool              // Exit has been called, no more pools can be added
+	shared     *sharedWorkers[T] // Workers shared by all pools, nil if every pool has its own
 }
 
 // Parameter key is the pool identifier, value is the corresponding pool configuration
@@ -18,25 +34,186 @@ func NewPoolSet[T interface{}](poolSet map[string][]Option[T]) *PoolSet[T] {
 	return &ps
 }
 
-func (p *PoolSet[T]) panicIfNotExist(name string) {
-	_, ok := p.nameToPool[name]
+// NewSharedPoolSet Pool set whose pools share a budget of size workers instead of starting their own.
+// Workers are distributed by WithWeight, a pool without queued tasks lends its share to busy pools
+// and WithMinShare guarantees a pool workers first when it has queued tasks. Size options of the pools
+// are ignored except WithMaxSize, which caps the workers serving a pool
+func NewSharedPoolSet[T interface{}](size int, poolSet map[string][]Option[T]) *PoolSet[T] {
+	ps := PoolSet[T]{
+		nameToPool: make(map[string]*Pool[T]),
+		shared:     newSharedWorkers[T](size),
+	}
+	for name, opts := range poolSet {
+		ps.nameToPool[name] = ps.newPool(opts)
+	}
+	return &ps
+}
+
+func (p *PoolSet[T]) newPool(opts []Option[T]) *Pool[T] {
+	if p.shared == nil {
+		return NewPool(opts...)
+	}
+	pool := NewPool(append(append([]Option[T]{}, opts...), withShared(p.shared))...)
+	p.shared.add(pool)
+	return pool
+}
+
+// Add Create a pool under name, fails with ErrPoolExists or ErrPoolClosed after Exit
+func (p *PoolSet[T]) Add(name string, opts ...Option[T]) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+	if _, ok := p.nameToPool[name]; ok {
+		return ErrPoolExists
+	}
+	p.nameToPool[name] = p.newPool(opts)
+	return nil
+}
+
+// Remove Unregister the pool under name and wait until its queued tasks are drained or ctx is done.
+// A paused pool is resumed first, once unregistered nobody could resume it any more
+func (p *PoolSet[T]) Remove(ctx context.Context, name string) error {
+	p.mu.Lock()
+	pool, ok := p.nameToPool[name]
+	delete(p.nameToPool, name)
+	p.mu.Unlock()
+
 	if !ok {
-		panic(fmt.Sprintf("%s not exist", name))
+		return ErrPoolNotFound
+	}
+	pool.Resume()
+	err := pool.Shutdown(ctx)
+	if p.shared != nil {
+		if err == nil {
+			p.shared.remove(pool)
+		} else {
+			// A pool given up on keeps being served until drained
+			go func() {
+				<-pool.doneCh
+				p.shared.remove(pool)
+			}()
+		}
+	}
+	return err
+}
+
+// Get Pool registered under name
+func (p *PoolSet[T]) Get(name string) (*Pool[T], bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pool, ok := p.nameToPool[name]
+	return pool, ok
+}
+
+// Names Identifiers of all registered pools in ascending order
+func (p *PoolSet[T]) Names() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := make([]string, 0, len(p.nameToPool))
+	for name := range p.nameToPool {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
 }
 
-func (p *PoolSet[T]) New(name string, t T) {
-	p.panicIfNotExist(name)
-	p.nameToPool[name].New(t)
+// pools Snapshot of registered pools, so pools can be used without holding the lock
+func (p *PoolSet[T]) pools() map[string]*Pool[T] {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pools := make(map[string]*Pool[T], len(p.nameToPool))
+	for name, pool := range p.nameToPool {
+		pools[name] = pool
+	}
+	return pools
 }
 
+// New Put task into the pool under name, blocks until the task is queued
+func (p *PoolSet[T]) New(name string, t T) error {
+	return p.Submit(context.Background(), name, t)
+}
+
+// Submit Put task into the pool under name, waits for free queue space until ctx is done.
+// Returns ErrPoolNotFound for an unknown name
+func (p *PoolSet[T]) Submit(ctx context.Context, name string, t T) error {
+	return p.SubmitWithPriority(ctx, name, t, PriorityNormal)
+}
+
+// SubmitWithPriority Same as Submit, higher priority tasks are served first
+func (p *PoolSet[T]) SubmitWithPriority(ctx context.Context, name string, t T, priority Priority) error {
+	pool, ok := p.Get(name)
+	if !ok {
+		return ErrPoolNotFound
+	}
+	return pool.SubmitWithPriority(ctx, t, priority)
+}
+
+// TrySubmit Put task into the pool under name without waiting, fails with ErrPoolNotFound,
+// ErrPoolFull or ErrPoolClosed
+func (p *PoolSet[T]) TrySubmit(name string, t T) error {
+	pool, ok := p.Get(name)
+	if !ok {
+		return ErrPoolNotFound
+	}
+	return pool.trySubmit(t, PriorityNormal)
+}
+
+// Pause Stop starting tasks in the pool under name, submissions are still queued
+func (p *PoolSet[T]) Pause(name string) error {
+	pool, ok := p.Get(name)
+	if !ok {
+		return ErrPoolNotFound
+	}
+	pool.Pause()
+	return nil
+}
+
+// Resume Start running queued tasks of the pool under name again
+func (p *PoolSet[T]) Resume(name string) error {
+	pool, ok := p.Get(name)
+	if !ok {
+		return ErrPoolNotFound
+	}
+	pool.Resume()
+	return nil
+}
+
+// Exit Stop accepting tasks in all pools, queued tasks are still finished. Does not wait
 func (p *PoolSet[T]) Exit() {
-	for _, p := range p.nameToPool {
-		p.Exit()
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	for _, pool := range p.pools() {
+		pool.Exit()
 	}
+	if p.shared != nil {
+		p.shared.close()
+	}
+}
+
+// Shutdown Stop accepting tasks in all pools and wait until every pool is drained or ctx is done
+func (p *PoolSet[T]) Shutdown(ctx context.Context) error {
+	p.Exit()
+	for _, pool := range p.pools() {
+		if err := pool.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// IsFull Whether all workers of the pool under name are busy, an unknown pool is reported full
 func (p *PoolSet[T]) IsFull(name string) bool {
-	p.panicIfNotExist(name)
-	return p.nameToPool[name].IsFull()
+	pool, ok := p.Get(name)
+	if !ok {
+		return true
+	}
+	return pool.IsFull()
 }
@@ -1,11 +1,14 @@
 package go_pool
 
 import (
+	"context"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 )
 
 func TestPoolSet(t *testing.T) {
@@ -45,6 +48,225 @@ func TestPoolSet(t *testing.T) {
 		assert.True(ok)
 	}
 
-	ps.Exit()
-	time.Sleep(100 * time.Millisecond)
+	assert.NoError(ps.Shutdown(context.Background()))
+	assert.Equal(int64(100), ps.Stats()["test"].Completed)
+}
+
+func TestPoolSetDynamic(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+	var dones atomic.Int32
+	opts := []Option[TaskForT]{
+		WithSize[TaskForT](2),
+		WithQueueSize[TaskForT](100),
+		WithTaskCB(func(t TaskForT, i int) {}),
+		WithDoneCB(func(t TaskForT, i int) {
+			dones.Add(1)
+		}),
+	}
+	ps := NewPoolSet(map[string][]Option[TaskForT]{})
+
+	// Concurrent registration of tenant pools
+	wg := sync.WaitGroup{}
+	errCh := make(chan error, 10*11)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("tenant-%d", i)
+			errCh <- ps.Add(name, opts...)
+			for j := 0; j < 10; j++ {
+				errCh <- ps.Submit(ctx, name, TaskForT{i: j})
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		assert.NoError(err)
+	}
+	assert.Len(ps.Names(), 10)
+	assert.ErrorIs(ps.Add("tenant-0", opts...), ErrPoolExists)
+
+	pool, ok := ps.Get("tenant-0")
+	assert.True(ok)
+	assert.NotNil(pool)
+	_, ok = ps.Get("unknown")
+	assert.False(ok)
+	assert.ErrorIs(ps.Submit(ctx, "unknown", TaskForT{}), ErrPoolNotFound)
+	assert.ErrorIs(ps.TrySubmit("unknown", TaskForT{}), ErrPoolNotFound)
+	assert.True(ps.IsFull("unknown"))
+	assert.ErrorIs(ps.Pause("unknown"), ErrPoolNotFound)
+	assert.ErrorIs(ps.Resume("unknown"), ErrPoolNotFound)
+	assert.NoError(ps.Pause("tenant-1"))
+	assert.True(ps.Stats()["tenant-1"].Paused)
+	assert.NoError(ps.Resume("tenant-1"))
+
+	// Removed pool is drained and no longer reachable
+	assert.NoError(ps.Remove(ctx, "tenant-0"))
+	assert.ErrorIs(ps.Remove(ctx, "tenant-0"), ErrPoolNotFound)
+	assert.ErrorIs(pool.Submit(ctx, TaskForT{}), ErrPoolClosed)
+	assert.NotContains(ps.Names(), "tenant-0")
+
+	assert.NoError(ps.Shutdown(ctx))
+	assert.Equal(int32(100), dones.Load())
+	assert.ErrorIs(ps.Add("late", opts...), ErrPoolClosed)
+	assert.ErrorIs(ps.TrySubmit("tenant-1", TaskForT{}), ErrPoolClosed)
+}
+
+func TestSharedPoolSet(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+	release := make(chan struct{})
+	running := map[string]*atomic.Int32{"a": atomic.NewInt32(0), "b": atomic.NewInt32(0), "c": atomic.NewInt32(0)}
+	busy := make(chan string, 100)
+	poolOpts := func(name string, opts ...Option[TaskForT]) []Option[TaskForT] {
+		return append(opts,
+			WithQueueSize[TaskForT](100),
+			WithTaskCB(func(t TaskForT, i int) {
+				running[name].Inc()
+				busy <- name
+				<-release
+				running[name].Dec()
+			}),
+		)
+	}
+	// runRound Occupy all workers with pool c, queue tasks in a and b, then hand the workers over one by one
+	runRound := func(ps *PoolSet[TaskForT]) (a, b int32) {
+		for i := 0; i < 4; i++ {
+			assert.NoError(ps.Submit(ctx, "c", TaskForT{i: i}))
+			<-busy
+		}
+		for i := 0; i < 8; i++ {
+			assert.NoError(ps.Submit(ctx, "a", TaskForT{i: i}))
+			assert.NoError(ps.Submit(ctx, "b", TaskForT{i: i}))
+		}
+		for i := 0; i < 4; i++ {
+			release <- struct{}{}
+			<-busy
+		}
+		a, b = running["a"].Load(), running["b"].Load()
+		close(release)
+		assert.NoError(ps.Shutdown(ctx))
+		for len(busy) > 0 {
+			<-busy
+		}
+		return a, b
+	}
+
+	// Workers are split by weight
+	ps := NewSharedPoolSet(4, map[string][]Option[TaskForT]{
+		"a": poolOpts("a", WithWeight[TaskForT](3)),
+		"b": poolOpts("b"),
+		"c": poolOpts("c"),
+	})
+	a, b := runRound(ps)
+	assert.Equal(int32(3), a)
+	assert.Equal(int32(1), b)
+	assert.Equal(int64(8), ps.Stats()["a"].Completed)
+
+	// Minimum share is served first
+	release = make(chan struct{})
+	ps = NewSharedPoolSet(4, map[string][]Option[TaskForT]{
+		"a": poolOpts("a", WithWeight[TaskForT](3)),
+		"b": poolOpts("b", WithMinShare[TaskForT](2)),
+		"c": poolOpts("c"),
+	})
+	a, b = runRound(ps)
+	assert.Equal(int32(2), a)
+	assert.Equal(int32(2), b)
+
+	// An idle pool lends its share
+	release = make(chan struct{})
+	ps = NewSharedPoolSet(4, map[string][]Option[TaskForT]{
+		"a": poolOpts("a"),
+		"b": poolOpts("b", WithWeight[TaskForT](3)),
+	})
+	assert.NoError(ps.Add("c", poolOpts("c")...))
+	for i := 0; i < 4; i++ {
+		assert.NoError(ps.Submit(ctx, "a", TaskForT{i: i}))
+		<-busy
+	}
+	assert.Equal(int32(4), running["a"].Load())
+	close(release)
+	assert.NoError(ps.Remove(ctx, "a"))
+	assert.NoError(ps.Shutdown(ctx))
+}
+
+func TestSharedPoolSetRemoveTimeout(t *testing.T) {
+	assert := require.New(t)
+	release := make(chan struct{})
+	ps := NewSharedPoolSet(1, map[string][]Option[TaskForT]{
+		"a": {WithTaskCB(func(t TaskForT, i int) { <-release })},
+	})
+	ps.shared.mu.Lock()
+	assert.Len(ps.shared.entries, 1)
+	ps.shared.mu.Unlock()
+
+	assert.NoError(ps.Submit(context.Background(), "a", TaskForT{}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(ps.Remove(ctx, "a"), context.DeadlineExceeded)
+
+	// The pool given up on stops being served once drained
+	close(release)
+	assert.Eventually(func() bool {
+		ps.shared.mu.Lock()
+		defer ps.shared.mu.Unlock()
+		return len(ps.shared.entries) == 0
+	}, time.Second, time.Millisecond)
+	assert.NoError(ps.Shutdown(context.Background()))
+}
+
+func TestSharedPoolSetPausedShutdown(t *testing.T) {
+	assert := require.New(t)
+	var dones atomic.Int32
+	ps := NewSharedPoolSet(2, map[string][]Option[TaskForT]{
+		"a": {WithQueueSize[TaskForT](10), WithTaskCB(func(t TaskForT, i int) { dones.Add(1) })},
+	})
+	assert.NoError(ps.Pause("a"))
+	for i := 0; i < 3; i++ {
+		assert.NoError(ps.Submit(context.Background(), "a", TaskForT{i: i}))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(ps.Shutdown(ctx), context.DeadlineExceeded)
+
+	// Shared workers stayed for the paused pool
+	assert.NoError(ps.Resume("a"))
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(ps.Shutdown(ctx))
+	assert.Equal(int32(3), dones.Load())
+}
+
+func TestPoolSetRemovePaused(t *testing.T) {
+	for _, shared := range []bool{false, true} {
+		t.Run(fmt.Sprintf("shared=%v", shared), func(t *testing.T) {
+			assert := require.New(t)
+			var dones atomic.Int32
+			pools := map[string][]Option[TaskForT]{
+				"a": {
+					WithSize[TaskForT](4),
+					WithQueueSize[TaskForT](10),
+					WithTaskCB(func(t TaskForT, i int) { dones.Add(1) }),
+				},
+			}
+			ps := NewPoolSet(pools)
+			if shared {
+				ps = NewSharedPoolSet(4, pools)
+			}
+			assert.NoError(ps.Pause("a"))
+			for i := 0; i < 5; i++ {
+				assert.NoError(ps.Submit(context.Background(), "a", TaskForT{i: i}))
+			}
+
+			// Removing the paused pool runs its queued tasks instead of leaving them stuck
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			assert.NoError(ps.Remove(ctx, "a"))
+			assert.Equal(int32(5), dones.Load())
+			assert.NoError(ps.Shutdown(ctx))
+		})
+	}
 }
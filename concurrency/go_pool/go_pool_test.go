@@ -1,11 +1,14 @@
 package go_pool
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 )
 
 type TaskForT struct {
@@ -46,6 +49,344 @@ func TestPool(t *testing.T) {
 		assert.True(ok)
 	}
 
+	assert.NoError(p.Shutdown(context.Background()))
+}
+
+func TestPoolSubmit(t *testing.T) {
+	assert := require.New(t)
+	blockCh := make(chan struct{})
+	p := NewPool(
+		WithSize[TaskForT](1),
+		WithQueueSize[TaskForT](1),
+		WithTaskCB(func(t TaskForT, i int) {
+			<-blockCh
+		}),
+	)
+
+	// First task occupies the only worker, second one fills the queue
+	assert.NoError(p.Submit(context.Background(), TaskForT{i: 0}))
+	assert.Eventually(p.IsFull, time.Second, time.Millisecond)
+	assert.True(p.TrySubmit(TaskForT{i: 1}))
+	assert.False(p.TrySubmit(TaskForT{i: 2}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := p.Submit(ctx, TaskForT{i: 2})
+	assert.ErrorIs(err, ErrPoolFull)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+
+	close(blockCh)
 	p.Exit()
-	time.Sleep(100 * time.Millisecond)
+	assert.ErrorIs(p.Submit(context.Background(), TaskForT{i: 3}), ErrPoolClosed)
+	assert.False(p.TrySubmit(TaskForT{i: 4}))
+}
+
+func TestPoolShutdown(t *testing.T) {
+	assert := require.New(t)
+	var dones atomic.Int32
+	p := NewPool(
+		WithSize[TaskForT](2),
+		WithQueueSize[TaskForT](100),
+		WithTaskCB(func(t TaskForT, i int) {
+			time.Sleep(time.Millisecond)
+		}),
+		WithDoneCB(func(t TaskForT, i int) {
+			dones.Add(1)
+		}),
+	)
+	for i := 0; i < 100; i++ {
+		assert.True(p.TrySubmit(TaskForT{i: i}))
+	}
+
+	// Queued tasks are drained before Shutdown returns
+	assert.NoError(p.Shutdown(context.Background()))
+	assert.Equal(int32(100), dones.Load())
+	assert.ErrorIs(p.Submit(context.Background(), TaskForT{}), ErrPoolClosed)
+
+	// Shutdown gives up when ctx is done first
+	blockCh := make(chan struct{})
+	p = NewPool(
+		WithSize[TaskForT](1),
+		WithTaskCB(func(t TaskForT, i int) {
+			<-blockCh
+		}),
+	)
+	assert.NoError(p.Submit(context.Background(), TaskForT{}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(p.Shutdown(ctx), context.DeadlineExceeded)
+	close(blockCh)
+	assert.NoError(p.Shutdown(context.Background()))
+}
+
+func TestPoolPanic(t *testing.T) {
+	assert := require.New(t)
+	var dones atomic.Int32
+	recovered := sync.Map{}
+	p := NewPool(
+		WithSize[TaskForT](1),
+		WithTaskCB(func(t TaskForT, i int) {
+			if t.i%2 == 0 {
+				panic(t.i)
+			}
+		}),
+		WithDoneCB(func(t TaskForT, i int) {
+			dones.Add(1)
+		}),
+		WithPanicHandler(func(t TaskForT, worker int, r any, stack []byte) {
+			recovered.Store(t.i, r)
+		}),
+	)
+	for i := 0; i < 10; i++ {
+		assert.NoError(p.Submit(context.Background(), TaskForT{i: i}))
+	}
+	assert.NoError(p.Shutdown(context.Background()))
+
+	// The single worker survived every panic and done callback still ran
+	assert.Equal(int32(10), dones.Load())
+	assert.Equal(int64(5), p.Panics())
+	for i := 0; i < 10; i += 2 {
+		r, ok := recovered.Load(i)
+		assert.True(ok)
+		assert.Equal(i, r)
+	}
+}
+
+func TestPoolElastic(t *testing.T) {
+	assert := require.New(t)
+	blockCh := make(chan struct{})
+	workers := sync.Map{}
+	p := NewPool(
+		WithMinSize[TaskForT](1),
+		WithMaxSize[TaskForT](4),
+		WithIdleTimeout[TaskForT](20*time.Millisecond),
+		WithTaskCB(func(t TaskForT, i int) {
+			workers.Store(i, struct{}{})
+			<-blockCh
+		}),
+	)
+	assert.Equal(1, p.Workers())
+
+	// Queued tasks make the pool grow up to max size
+	for i := 0; i < 8; i++ {
+		assert.NoError(p.Submit(context.Background(), TaskForT{i: i}))
+	}
+	assert.Eventually(func() bool {
+		return p.Workers() == 4 && p.IsFull()
+	}, time.Second, time.Millisecond)
+
+	// Idle workers are retired down to min size
+	close(blockCh)
+	assert.Eventually(func() bool {
+		return p.Workers() == 1
+	}, time.Second, time.Millisecond)
+	workers.Range(func(key, value any) bool {
+		assert.Less(key.(int), 4)
+		return true
+	})
+
+	assert.NoError(p.Shutdown(context.Background()))
+	assert.Equal(0, p.Workers())
+}
+
+func TestPoolPriority(t *testing.T) {
+	assert := require.New(t)
+	startCh, blockCh := make(chan struct{}), make(chan struct{})
+	var order []int
+	p := NewPool(
+		WithSize[TaskForT](1),
+		WithQueueSize[TaskForT](10),
+		WithAging[TaskForT](50*time.Millisecond),
+		WithTaskCB(func(t TaskForT, i int) {
+			if t.i < 0 {
+				close(startCh)
+				<-blockCh
+				return
+			}
+			order = append(order, t.i)
+		}),
+	)
+	ctx := context.Background()
+	assert.NoError(p.Submit(ctx, TaskForT{i: -1}))
+	<-startCh
+
+	// Low priority task waited long enough to be promoted above high priority
+	assert.NoError(p.SubmitWithPriority(ctx, TaskForT{i: 0}, PriorityLow))
+	time.Sleep(200 * time.Millisecond)
+	assert.NoError(p.SubmitWithPriority(ctx, TaskForT{i: 4}, PriorityLow))
+	assert.NoError(p.SubmitWithPriority(ctx, TaskForT{i: 3}, PriorityNormal))
+	assert.True(p.TrySubmitWithPriority(TaskForT{i: 1}, PriorityHigh))
+	assert.True(p.TrySubmitWithPriority(TaskForT{i: 2}, PriorityHigh))
+	close(blockCh)
+
+	assert.NoError(p.Shutdown(ctx))
+	assert.Equal([]int{0, 1, 2, 3, 4}, order)
+}
+
+func TestPoolTaskTimeout(t *testing.T) {
+	assert := require.New(t)
+	errs := sync.Map{}
+	cancelled := make(chan int, 10)
+	p := NewPool(
+		WithSize[TaskForT](1),
+		WithTaskTimeout[TaskForT](20*time.Millisecond),
+		WithTaskCtxCB(func(ctx context.Context, t TaskForT, i int) {
+			if t.i == 0 {
+				// Stuck task only stops once its context is cancelled
+				<-ctx.Done()
+				time.Sleep(10 * time.Millisecond)
+			}
+			cancelled <- t.i
+		}),
+		WithDoneErrCB(func(t TaskForT, i int, err error) {
+			errs.Store(t.i, err)
+		}),
+	)
+	ctx := context.Background()
+	assert.NoError(p.Submit(ctx, TaskForT{i: 0}))
+	assert.NoError(p.Submit(ctx, TaskForT{i: 1}))
+	assert.NoError(p.Shutdown(ctx))
+
+	err, _ := errs.Load(0)
+	assert.ErrorIs(err.(error), ErrTaskTimeout)
+	err, _ = errs.Load(1)
+	assert.Nil(err)
+	// The worker waited for the stuck callback before running the next task
+	assert.Equal(0, <-cancelled)
+	assert.Equal(1, <-cancelled)
+	assert.Equal(int64(1), p.Stats().Failed)
+}
+
+func TestPoolRateLimit(t *testing.T) {
+	assert := require.New(t)
+	p := NewPool(
+		WithSize[TaskForT](1),
+		WithQueueSize[TaskForT](10),
+		WithRateLimit[TaskForT](20, 2),
+		WithTaskCB(func(t TaskForT, i int) {}),
+	)
+	// Burst is admitted right away, then tokens run out
+	assert.True(p.TrySubmit(TaskForT{i: 0}))
+	assert.True(p.TrySubmit(TaskForT{i: 1}))
+	assert.False(p.TrySubmit(TaskForT{i: 2}))
+
+	// Next token arrives after 50ms
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(p.Submit(ctx, TaskForT{i: 3}), ErrRateLimited)
+
+	start := time.Now()
+	assert.NoError(p.Submit(context.Background(), TaskForT{i: 4}))
+	assert.Greater(time.Since(start), 20*time.Millisecond)
+	assert.NoError(p.Shutdown(context.Background()))
+
+	stats := p.Stats()
+	assert.Equal(int64(3), stats.Submitted)
+	assert.Equal(int64(3), stats.Throttled)
+	assert.Greater(stats.AvgThrottleWait, time.Duration(0))
+}
+
+func TestPoolRetry(t *testing.T) {
+	assert := require.New(t)
+	errTemporary := errors.New("temporary")
+	errFatal := errors.New("fatal")
+	attempts := sync.Map{}
+	doneErrs := sync.Map{}
+	deadLetters := make(chan int, 10)
+	p := NewPool(
+		WithSize[TaskForT](2),
+		WithRetry[TaskForT](3, ExponentialBackoff(time.Millisecond, 5*time.Millisecond, 0.5)),
+		WithRetryable[TaskForT](func(err error) bool {
+			return errors.Is(err, errTemporary)
+		}),
+		WithTaskErrCB(func(ctx context.Context, t TaskForT, i int) error {
+			n, _ := attempts.LoadOrStore(t.i, atomic.NewInt32(0))
+			attempt := n.(*atomic.Int32).Inc()
+			switch {
+			case t.i == 1:
+				return errFatal
+			case t.i == 2 || attempt < 3:
+				return errTemporary
+			}
+			return nil
+		}),
+		WithDoneErrCB(func(t TaskForT, i int, err error) {
+			doneErrs.Store(t.i, err)
+		}),
+		WithDeadLetterCB(func(t TaskForT, i int, err error) {
+			deadLetters <- t.i
+		}),
+	)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		assert.NoError(p.Submit(ctx, TaskForT{i: i}))
+	}
+	assert.NoError(p.Shutdown(ctx))
+	close(deadLetters)
+
+	// Task 0 succeeds on the last attempt, task 1 is not retryable, task 2 runs out of attempts
+	expected := map[int]struct {
+		attempts int32
+		err      error
+	}{0: {3, nil}, 1: {1, errFatal}, 2: {3, errTemporary}}
+	for i, e := range expected {
+		n, _ := attempts.Load(i)
+		assert.Equal(e.attempts, n.(*atomic.Int32).Load())
+		err, _ := doneErrs.Load(i)
+		if e.err == nil {
+			assert.Nil(err)
+		} else {
+			assert.ErrorIs(err.(error), e.err)
+		}
+	}
+	var dead []int
+	for i := range deadLetters {
+		dead = append(dead, i)
+	}
+	assert.ElementsMatch([]int{1, 2}, dead)
+	stats := p.Stats()
+	assert.Equal(int64(2), stats.Failed)
+	assert.Equal(int64(4), stats.Retried)
+}
+
+func TestPoolPause(t *testing.T) {
+	assert := require.New(t)
+	started := make(chan int, 10)
+	release := make(chan struct{})
+	p := NewPool(
+		WithMinSize[TaskForT](1),
+		WithMaxSize[TaskForT](4),
+		WithQueueSize[TaskForT](10),
+		WithTaskCB(func(t TaskForT, i int) {
+			started <- t.i
+			<-release
+		}),
+	)
+	ctx := context.Background()
+	assert.NoError(p.Submit(ctx, TaskForT{i: 0}))
+	assert.Equal(0, <-started)
+
+	// Running task is finished, queued tasks wait for resume
+	p.Pause()
+	assert.True(p.Paused())
+	for i := 1; i <= 4; i++ {
+		assert.NoError(p.Submit(ctx, TaskForT{i: i}))
+	}
+	close(release)
+	select {
+	case i := <-started:
+		assert.Fail("task started while paused", i)
+	case <-time.After(50 * time.Millisecond):
+	}
+	stats := p.Stats()
+	assert.True(stats.Paused)
+	assert.Equal(4, stats.Queued)
+	// Queued tasks do not add workers while paused
+	assert.LessOrEqual(stats.Workers, 2)
+
+	p.Resume()
+	assert.False(p.Paused())
+	assert.NoError(p.Shutdown(ctx))
+	assert.Len(started, 4)
+	assert.Equal(int64(5), p.Stats().Completed)
 }
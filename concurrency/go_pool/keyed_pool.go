@@ -0,0 +1,151 @@
+package go_pool
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"time"
+)
+
+// KeyedPool Goroutine pool where tasks sharing a key run one at a time in submission order,
+// while tasks of different keys run in parallel. Keys are sharded over single worker pools,
+// worker index passed to callbacks is the shard index
+type KeyedPool[K comparable, T interface{}] struct {
+	shards []*Pool[T]
+}
+
+// NewKeyedPool Number of shards is the pool size (max size for elastic options), queue size applies per shard.
+// Rate limit and stats reporter apply to the whole pool: shards share one token bucket and stats are reported
+// summed over shards
+func NewKeyedPool[K comparable, T interface{}](opts ...Option[T]) *KeyedPool[K, T] {
+	o := options[T]{}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	size := o.size
+	if o.maxSize > 0 {
+		size = o.maxSize
+	}
+	if size <= 0 {
+		log.Fatal("size is less then or equal 0")
+	}
+	queueSize := o.queueSize
+	if queueSize <= 0 {
+		// Default per shard queue holds one pending task per worker of the whole pool
+		queueSize = size
+	}
+
+	var limiter *tokenBucket
+	if o.rateLimit > 0 {
+		limiter = newTokenBucket(o.rateLimit, o.rateBurst)
+	}
+
+	p := KeyedPool[K, T]{
+		shards: make([]*Pool[T], size),
+	}
+	for i := range p.shards {
+		shardOpts := append(append([]Option[T]{}, opts...),
+			WithSize[T](1),
+			WithMinSize[T](1),
+			WithMaxSize[T](1),
+			WithQueueSize[T](queueSize),
+			WithRateLimit[T](0, 0),
+			WithStatsReporter[T](0, nil),
+			withLimiter[T](limiter),
+			withWorkerBase[T](i),
+		)
+		p.shards[i] = NewPool(shardOpts...)
+	}
+	if o.statsCB != nil && o.statsInterval > 0 {
+		go p.reportStats(o.statsInterval, o.statsCB)
+	}
+	return &p
+}
+
+// Shard Index of the shard serving key
+func (p *KeyedPool[K, T]) Shard(key K) int {
+	h := fnv.New32a()
+	_, _ = fmt.Fprint(h, key)
+	return int(h.Sum32() % uint32(len(p.shards)))
+}
+
+// Submit Put task into the shard of key, waits for free queue space until ctx is done
+func (p *KeyedPool[K, T]) Submit(ctx context.Context, key K, t T) error {
+	return p.shards[p.Shard(key)].Submit(ctx, t)
+}
+
+// TrySubmit Put task into the shard of key without waiting, returns false if the shard is full or closed
+func (p *KeyedPool[K, T]) TrySubmit(key K, t T) bool {
+	return p.shards[p.Shard(key)].TrySubmit(t)
+}
+
+// Exit Stop accepting tasks, queued tasks are still finished. Does not wait
+func (p *KeyedPool[K, T]) Exit() {
+	for _, shard := range p.shards {
+		shard.Exit()
+	}
+}
+
+// Shutdown Stop accepting tasks and wait until all shards are drained or ctx is done
+func (p *KeyedPool[K, T]) Shutdown(ctx context.Context) error {
+	p.Exit()
+	for _, shard := range p.shards {
+		if err := shard.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Panics Number of panics recovered over all shards
+func (p *KeyedPool[K, T]) Panics() (panics int64) {
+	for _, shard := range p.shards {
+		panics += shard.Panics()
+	}
+	return panics
+}
+
+// Stats Snapshot of the pool state summed over shards
+func (p *KeyedPool[K, T]) Stats() Stats {
+	stats := Stats{}
+	latency, queueWait, throttleWait := latencyRecorder{}, latencyRecorder{}, latencyRecorder{}
+	for _, shard := range p.shards {
+		s := shard.Stats()
+		stats.Workers += s.Workers
+		stats.Busy += s.Busy
+		stats.Queued += s.Queued
+		stats.Paused = stats.Paused || s.Paused
+		stats.Submitted += s.Submitted
+		stats.Completed += s.Completed
+		stats.Failed += s.Failed
+		stats.Retried += s.Retried
+		stats.Panicked += s.Panicked
+		stats.Throttled += s.Throttled
+		latency.merge(&shard.latency)
+		queueWait.merge(&shard.queueWait)
+		throttleWait.merge(&shard.throttleWait)
+	}
+	stats.AvgLatency = latency.avg()
+	stats.P99Latency = latency.percentile(0.99)
+	stats.AvgQueueWait = queueWait.avg()
+	stats.AvgThrottleWait = throttleWait.avg()
+	return stats
+}
+
+// reportStats Periodically hand stats to the reporter until all shards have exited, a last report is made on exit
+func (p *KeyedPool[K, T]) reportStats(interval time.Duration, statsCB func(stats Stats)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for _, shard := range p.shards {
+		for exited := false; !exited; {
+			select {
+			case <-ticker.C:
+				statsCB(p.Stats())
+			case <-shard.doneCh:
+				exited = true
+			}
+		}
+	}
+	statsCB(p.Stats())
+}
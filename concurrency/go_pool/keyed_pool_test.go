@@ -0,0 +1,114 @@
+package go_pool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type keyedTask struct {
+	userID int64
+	seq    int
+}
+
+func TestKeyedPool(t *testing.T) {
+	assert := require.New(t)
+	mu := sync.Mutex{}
+	seqs := make(map[int64][]int)
+	workers := make(map[int64][]int)
+	p := NewKeyedPool[int64](
+		WithSize[keyedTask](4),
+		WithTaskCB(func(t keyedTask, i int) {
+			mu.Lock()
+			defer mu.Unlock()
+			seqs[t.userID] = append(seqs[t.userID], t.seq)
+			workers[t.userID] = append(workers[t.userID], i)
+		}),
+	)
+
+	ctx := context.Background()
+	for seq := 0; seq < 100; seq++ {
+		for userID := int64(0); userID < 10; userID++ {
+			assert.NoError(p.Submit(ctx, userID, keyedTask{userID: userID, seq: seq}))
+		}
+	}
+	assert.NoError(p.Shutdown(ctx))
+
+	// Every key saw its tasks in submission order
+	for userID := int64(0); userID < 10; userID++ {
+		assert.Len(seqs[userID], 100)
+		for seq := range seqs[userID] {
+			assert.Equal(seq, seqs[userID][seq])
+			// Worker index is the shard of the key
+			assert.Equal(p.Shard(userID), workers[userID][seq])
+		}
+	}
+}
+
+func TestKeyedPoolTaskTimeout(t *testing.T) {
+	assert := require.New(t)
+	mu := sync.Mutex{}
+	var seqs []int
+	p := NewKeyedPool[int64](
+		WithSize[keyedTask](2),
+		WithTaskTimeout[keyedTask](10*time.Millisecond),
+		WithTaskCtxCB(func(ctx context.Context, t keyedTask, i int) {
+			if t.seq == 0 {
+				// Timed out task keeps running past its timeout
+				<-ctx.Done()
+				time.Sleep(20 * time.Millisecond)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			seqs = append(seqs, t.seq)
+		}),
+	)
+
+	ctx := context.Background()
+	for seq := 0; seq < 3; seq++ {
+		assert.NoError(p.Submit(ctx, 1, keyedTask{userID: 1, seq: seq}))
+	}
+	assert.NoError(p.Shutdown(ctx))
+	// Tasks of the key ran one after another despite the timeout
+	assert.Equal([]int{0, 1, 2}, seqs)
+}
+
+func TestKeyedPoolRateLimitAndStats(t *testing.T) {
+	assert := require.New(t)
+	reports := make(chan Stats, 100)
+	p := NewKeyedPool[int64](
+		WithSize[keyedTask](4),
+		WithQueueSize[keyedTask](10),
+		WithRateLimit[keyedTask](20, 2),
+		WithTaskCB(func(t keyedTask, i int) {}),
+		WithStatsReporter[keyedTask](5*time.Millisecond, func(stats Stats) {
+			reports <- stats
+		}),
+	)
+	// Shards share one token bucket, the burst is spent after two keys whatever their shard
+	assert.True(p.TrySubmit(0, keyedTask{userID: 0}))
+	assert.True(p.TrySubmit(1, keyedTask{userID: 1}))
+	for userID := int64(2); userID < 10; userID++ {
+		assert.False(p.TrySubmit(userID, keyedTask{userID: userID}))
+	}
+	assert.NoError(p.Shutdown(context.Background()))
+
+	stats := p.Stats()
+	assert.Equal(int64(2), stats.Submitted)
+	assert.Equal(int64(2), stats.Completed)
+	assert.Equal(int64(8), stats.Throttled)
+
+	// Reports are summed over shards
+	var last Stats
+	assert.Eventually(func() bool {
+		select {
+		case last = <-reports:
+		default:
+		}
+		return last.Completed == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(stats.Throttled, last.Throttled)
+}
@@ -1,17 +1,49 @@
 package go_pool
 
+import (
+	"context"
+	"time"
+)
+
 type options[T any] struct {
-	size   int
-	taskCB func(t T, i int)
-	doneCB func(t T, i int)
-	debug  bool
+	size          int
+	minSize       int
+	maxSize       int
+	idleTimeout   time.Duration
+	queueSize     int
+	aging         time.Duration
+	taskCB        func(t T, i int)
+	taskCtxCB     func(ctx context.Context, t T, i int)
+	taskErrCB     func(ctx context.Context, t T, i int) error
+	taskTimeout   time.Duration
+	doneCB        func(t T, i int)
+	doneErrCB     func(t T, i int, err error)
+	maxAttempts   int
+	backoff       Backoff
+	retryable     func(err error) bool
+	deadLetterCB  func(t T, i int, err error)
+	panicCB       func(t T, worker int, recovered any, stack []byte)
+	debug         bool
+	statsInterval time.Duration
+	statsCB       func(stats Stats)
+	rateLimit     float64
+	rateBurst     int
+	limiter       *tokenBucket // Rate limit shared with other pools, used instead of rateLimit
+	batchSize     int
+	flushInterval time.Duration
+	batchCB       func(items []T, worker int)
+	batchErrCB    func(items []T, worker int) []error
+	weight        int
+	minShare      int
+	shared        *sharedWorkers[T] // Workers owned by a shared PoolSet, the pool starts none of its own
+	workerBase    int               // First worker index, lets pools composed of several pools report distinct indexes
 }
 
 type Option[T any] interface {
 	apply(*options[T])
 }
 
-// Pool size
+// Pool size, a fixed number of workers unless WithMinSize/WithMaxSize are set
 type sizeOption[T any] int
 
 func (s sizeOption[T]) apply(o *options[T]) {
@@ -22,6 +54,79 @@ func WithSize[T any](size int) Option[T] {
 	return sizeOption[T](size)
 }
 
+// Minimum number of workers kept alive in an elastic pool, defaults to size
+type minSizeOption[T any] int
+
+func (m minSizeOption[T]) apply(o *options[T]) {
+	o.minSize = int(m)
+}
+
+func WithMinSize[T any](size int) Option[T] {
+	return minSizeOption[T](size)
+}
+
+// Maximum number of workers of an elastic pool, defaults to size.
+// Workers are added when tasks queue up and retired after idle timeout down to min size
+type maxSizeOption[T any] int
+
+func (m maxSizeOption[T]) apply(o *options[T]) {
+	o.maxSize = int(m)
+}
+
+func WithMaxSize[T any](size int) Option[T] {
+	return maxSizeOption[T](size)
+}
+
+// How long a worker above min size may stay idle before being retired, defaults to 3 minutes
+type idleTimeoutOption[T any] time.Duration
+
+func (i idleTimeoutOption[T]) apply(o *options[T]) {
+	o.idleTimeout = time.Duration(i)
+}
+
+func WithIdleTimeout[T any](timeout time.Duration) Option[T] {
+	return idleTimeoutOption[T](timeout)
+}
+
+// Pending task queue capacity, defaults to max size
+type queueSizeOption[T any] int
+
+func (q queueSizeOption[T]) apply(o *options[T]) {
+	o.queueSize = int(q)
+}
+
+func WithQueueSize[T any](size int) Option[T] {
+	return queueSizeOption[T](size)
+}
+
+// Waiting time after which a queued task is promoted one priority level, defaults to 1 second.
+// Guarantees low priority tasks eventually run under a flood of higher priority ones
+type agingOption[T any] time.Duration
+
+func (a agingOption[T]) apply(o *options[T]) {
+	o.aging = time.Duration(a)
+}
+
+func WithAging[T any](aging time.Duration) Option[T] {
+	return agingOption[T](aging)
+}
+
+// Task admission rate limit, submissions beyond rps tasks per second with bursts up to burst
+// wait for a token in Submit and fail in TrySubmit
+type rateLimitOption[T any] struct {
+	rps   float64
+	burst int
+}
+
+func (r rateLimitOption[T]) apply(o *options[T]) {
+	o.rateLimit = r.rps
+	o.rateBurst = r.burst
+}
+
+func WithRateLimit[T any](rps float64, burst int) Option[T] {
+	return rateLimitOption[T]{rps: rps, burst: burst}
+}
+
 // Pool task callback
 type taskCBOption[T any] func(t T, i int)
 
@@ -33,6 +138,43 @@ func WithTaskCB[T any](taskCB func(t T, i int)) Option[T] {
 	return taskCBOption[T](taskCB)
 }
 
+// Pool task callback receiving the task context, which is cancelled on task timeout
+// or when Shutdown gives up waiting. Used instead of WithTaskCB
+type taskCtxCBOption[T any] func(ctx context.Context, t T, i int)
+
+func (t taskCtxCBOption[T]) apply(o *options[T]) {
+	o.taskCtxCB = t
+}
+
+func WithTaskCtxCB[T any](taskCtxCB func(ctx context.Context, t T, i int)) Option[T] {
+	return taskCtxCBOption[T](taskCtxCB)
+}
+
+// Pool task callback receiving the task context and reporting failure with an error, the error is passed
+// to WithDoneErrCB and decides about retries. Used instead of WithTaskCB
+type taskErrCBOption[T any] func(ctx context.Context, t T, i int) error
+
+func (t taskErrCBOption[T]) apply(o *options[T]) {
+	o.taskErrCB = t
+}
+
+func WithTaskErrCB[T any](taskErrCB func(ctx context.Context, t T, i int) error) Option[T] {
+	return taskErrCBOption[T](taskErrCB)
+}
+
+// Maximum duration of a task callback. A task running longer has its context cancelled and is reported
+// with ErrTaskTimeout once the callback returns. The worker is held until then, so callbacks ignoring
+// their context are not cut short and the pool never runs more callbacks than workers
+type taskTimeoutOption[T any] time.Duration
+
+func (t taskTimeoutOption[T]) apply(o *options[T]) {
+	o.taskTimeout = time.Duration(t)
+}
+
+func WithTaskTimeout[T any](timeout time.Duration) Option[T] {
+	return taskTimeoutOption[T](timeout)
+}
+
 // Pool completion callback
 type doneCBOption[T any] func(t T, i int)
 
@@ -44,6 +186,151 @@ func WithDoneCB[T any](exitCB func(t T, i int)) Option[T] {
 	return doneCBOption[T](exitCB)
 }
 
+// Pool completion callback receiving the task outcome after retries: nil, the task callback error,
+// ErrTaskTimeout, ErrTaskPanic or the task context error
+type doneErrCBOption[T any] func(t T, i int, err error)
+
+func (d doneErrCBOption[T]) apply(o *options[T]) {
+	o.doneErrCB = d
+}
+
+func WithDoneErrCB[T any](doneErrCB func(t T, i int, err error)) Option[T] {
+	return doneErrCBOption[T](doneErrCB)
+}
+
+// Retry policy, a failed task is run again up to maxAttempts runs in total, waiting backoff(attempt)
+// in between on the same worker. Task panics are not retried unless WithRetryable says so
+type retryOption[T any] struct {
+	maxAttempts int
+	backoff     Backoff
+}
+
+func (r retryOption[T]) apply(o *options[T]) {
+	o.maxAttempts = r.maxAttempts
+	o.backoff = r.backoff
+}
+
+func WithRetry[T any](maxAttempts int, backoff Backoff) Option[T] {
+	return retryOption[T]{maxAttempts: maxAttempts, backoff: backoff}
+}
+
+// Classifier deciding whether a failed task is worth retrying
+type retryableOption[T any] func(err error) bool
+
+func (r retryableOption[T]) apply(o *options[T]) {
+	o.retryable = r
+}
+
+func WithRetryable[T any](retryable func(err error) bool) Option[T] {
+	return retryableOption[T](retryable)
+}
+
+// Dead letter callback, called with tasks still failing once retries are exhausted or the error is not retryable
+type deadLetterCBOption[T any] func(t T, i int, err error)
+
+func (d deadLetterCBOption[T]) apply(o *options[T]) {
+	o.deadLetterCB = d
+}
+
+func WithDeadLetterCB[T any](deadLetterCB func(t T, i int, err error)) Option[T] {
+	return deadLetterCBOption[T](deadLetterCB)
+}
+
+// Panic handler, called with the recovered value after a task or done callback panics.
+// The worker survives and keeps serving, panics are logged if no handler is set
+type panicCBOption[T any] func(t T, worker int, recovered any, stack []byte)
+
+func (p panicCBOption[T]) apply(o *options[T]) {
+	o.panicCB = p
+}
+
+func WithPanicHandler[T any](panicCB func(t T, worker int, recovered any, stack []byte)) Option[T] {
+	return panicCBOption[T](panicCB)
+}
+
+// Stats reporter, called with a stats snapshot every interval and once more after the pool has exited
+type statsOption[T any] struct {
+	interval time.Duration
+	statsCB  func(stats Stats)
+}
+
+func (s statsOption[T]) apply(o *options[T]) {
+	o.statsInterval = s.interval
+	o.statsCB = s.statsCB
+}
+
+func WithStatsReporter[T any](interval time.Duration, statsCB func(stats Stats)) Option[T] {
+	return statsOption[T]{interval: interval, statsCB: statsCB}
+}
+
+// Number of items handed to the batch callback of a BatchPool at most
+type batchSizeOption[T any] int
+
+func (b batchSizeOption[T]) apply(o *options[T]) {
+	o.batchSize = int(b)
+}
+
+func WithBatchSize[T any](size int) Option[T] {
+	return batchSizeOption[T](size)
+}
+
+// How long a BatchPool waits for a batch to fill up before running it anyway, batches only run when full if not set
+type flushIntervalOption[T any] time.Duration
+
+func (f flushIntervalOption[T]) apply(o *options[T]) {
+	o.flushInterval = time.Duration(f)
+}
+
+func WithFlushInterval[T any](interval time.Duration) Option[T] {
+	return flushIntervalOption[T](interval)
+}
+
+// BatchPool batch callback, every item of the batch succeeds unless the callback panics or times out
+type batchCBOption[T any] func(items []T, worker int)
+
+func (b batchCBOption[T]) apply(o *options[T]) {
+	o.batchCB = b
+}
+
+func WithBatchCB[T any](batchCB func(items []T, worker int)) Option[T] {
+	return batchCBOption[T](batchCB)
+}
+
+// BatchPool batch callback reporting per item outcomes, the returned errors are indexed like items.
+// A nil or short slice means success for the remaining items. Used instead of WithBatchCB
+type batchErrCBOption[T any] func(items []T, worker int) []error
+
+func (b batchErrCBOption[T]) apply(o *options[T]) {
+	o.batchErrCB = b
+}
+
+func WithBatchErrCB[T any](batchErrCB func(items []T, worker int) []error) Option[T] {
+	return batchErrCBOption[T](batchErrCB)
+}
+
+// Share of the workers of a shared PoolSet relative to the other pools, defaults to 1
+type weightOption[T any] int
+
+func (w weightOption[T]) apply(o *options[T]) {
+	o.weight = int(w)
+}
+
+func WithWeight[T any](weight int) Option[T] {
+	return weightOption[T](weight)
+}
+
+// Number of workers of a shared PoolSet guaranteed to the pool while it has queued tasks,
+// free workers serve pools below their minimum share first
+type minShareOption[T any] int
+
+func (m minShareOption[T]) apply(o *options[T]) {
+	o.minShare = int(m)
+}
+
+func WithMinShare[T any](minShare int) Option[T] {
+	return minShareOption[T](minShare)
+}
+
 // Debug logging
 type debugOption[T any] bool
 
@@ -54,3 +341,47 @@ func (d debugOption[T]) apply(o *options[T]) {
 func WithDebug[T any](debug bool) Option[T] {
 	return debugOption[T](debug)
 }
+
+// First worker index, used by pools built on several pools
+type workerBaseOption[T any] int
+
+func (w workerBaseOption[T]) apply(o *options[T]) {
+	o.workerBase = int(w)
+}
+
+func withWorkerBase[T any](base int) Option[T] {
+	return workerBaseOption[T](base)
+}
+
+// Token bucket shared with other pools, used by pools built on several pools
+type limiterOption[T any] struct {
+	limiter *tokenBucket
+}
+
+func (l limiterOption[T]) apply(o *options[T]) {
+	o.limiter = l.limiter
+}
+
+func withLimiter[T any](limiter *tokenBucket) Option[T] {
+	return limiterOption[T]{limiter: limiter}
+}
+
+// Workers of a shared PoolSet serving the pool
+type sharedOption[T any] struct {
+	shared *sharedWorkers[T]
+}
+
+func (s sharedOption[T]) apply(o *options[T]) {
+	o.shared = s.shared
+}
+
+func withShared[T any](shared *sharedWorkers[T]) Option[T] {
+	return sharedOption[T]{shared: shared}
+}
+
+// Complete configuration, used by pools built on a pool of another task type
+type optionsOption[T any] options[T]
+
+func (c optionsOption[T]) apply(o *options[T]) {
+	*o = options[T](c)
+}
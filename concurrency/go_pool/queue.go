@@ -0,0 +1,202 @@
+package go_pool
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Priority Scheduling priority of a task, higher priorities are served first
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+
+	priorityLevels = int(PriorityHigh) + 1
+)
+
+// queueItem Task waiting in the queue
+type queueItem[T any] struct {
+	t          T
+	enqueuedAt time.Time
+}
+
+// queue Bounded priority queue of pending tasks shared by submitters and workers, can be called concurrently.
+// Tasks of the same priority are FIFO, a waiting task is promoted one level per aging interval
+// so low priority tasks eventually run
+type queue[T any] struct {
+	mu       sync.Mutex
+	levels   [priorityLevels]*list.List // Pending tasks indexed by priority
+	size     int                        // Number of pending tasks over all levels
+	capacity int                        // Maximum number of pending tasks
+	aging    time.Duration              // Waiting time worth one priority level
+	closed   bool                       // No more pushes accepted after close
+	paused   bool                       // Pops return nothing until resume
+	resumeCh chan struct{}              // Closed on resume, wakes up workers waiting while paused
+	pushCh   chan struct{}              // Closed and replaced after every push, wakes up waiting workers
+	popCh    chan struct{}              // Closed and replaced after every pop, wakes up waiting submitters
+}
+
+func newQueue[T any](capacity int, aging time.Duration) *queue[T] {
+	q := &queue[T]{
+		capacity: capacity,
+		aging:    aging,
+		pushCh:   make(chan struct{}),
+		popCh:    make(chan struct{}),
+	}
+	for i := range q.levels {
+		q.levels[i] = list.New()
+	}
+	return q
+}
+
+// tryPush Append t without waiting, fails with ErrPoolFull or ErrPoolClosed
+func (q *queue[T]) tryPush(t T, priority Priority) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pushLocked(t, priority)
+}
+
+// push Append t, waits for free space until ctx is done
+func (q *queue[T]) push(ctx context.Context, t T, priority Priority) error {
+	for {
+		q.mu.Lock()
+		err := q.pushLocked(t, priority)
+		popCh := q.popCh
+		q.mu.Unlock()
+		if err != ErrPoolFull {
+			return err
+		}
+
+		select {
+		case <-popCh:
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrPoolFull, ctx.Err())
+		}
+	}
+}
+
+func (q *queue[T]) pushLocked(t T, priority Priority) error {
+	if q.closed {
+		return ErrPoolClosed
+	}
+	if q.size >= q.capacity {
+		return ErrPoolFull
+	}
+	if priority < PriorityLow {
+		priority = PriorityLow
+	} else if priority > PriorityHigh {
+		priority = PriorityHigh
+	}
+	q.levels[priority].PushBack(&queueItem[T]{
+		t:          t,
+		enqueuedAt: time.Now(),
+	})
+	q.size++
+	close(q.pushCh)
+	q.pushCh = make(chan struct{})
+	return nil
+}
+
+// pop Remove the task with the highest aged priority. When the queue is empty ok is false
+// and wait is closed on the next push, when paused wait is closed on resume.
+// wait is nil if the queue is closed and drained
+func (q *queue[T]) pop() (item queueItem[T], ok bool, wait <-chan struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.size == 0 && q.closed {
+		return item, false, nil
+	}
+	if q.paused {
+		return item, false, q.resumeCh
+	}
+	if q.size == 0 {
+		return item, false, q.pushCh
+	}
+
+	// Only the head of each level needs checking, it is the oldest task of its level
+	now := time.Now()
+	var best *list.Element
+	bestLevel, bestScore := 0, -1
+	for level := priorityLevels - 1; level >= 0; level-- {
+		front := q.levels[level].Front()
+		if front == nil {
+			continue
+		}
+		score := level
+		if q.aging > 0 {
+			score += int(now.Sub(front.Value.(*queueItem[T]).enqueuedAt) / q.aging)
+		}
+		if score > bestScore {
+			best, bestLevel, bestScore = front, level, score
+		}
+	}
+	q.levels[bestLevel].Remove(best)
+	q.size--
+	close(q.popCh)
+	q.popCh = make(chan struct{})
+	return *best.Value.(*queueItem[T]), true, nil
+}
+
+// pause Stop handing out tasks, pushes are still accepted
+func (q *queue[T]) pause() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.paused {
+		return
+	}
+	q.paused = true
+	q.resumeCh = make(chan struct{})
+}
+
+// resume Hand out tasks again and wake up workers waiting while paused
+func (q *queue[T]) resume() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.paused {
+		return
+	}
+	q.paused = false
+	close(q.resumeCh)
+}
+
+// ready Whether pop would hand out a task right now
+func (q *queue[T]) ready() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size > 0 && !q.paused
+}
+
+func (q *queue[T]) isPaused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.paused
+}
+
+// close Reject further pushes and wake up everyone waiting on the queue
+func (q *queue[T]) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.pushCh)
+	q.pushCh = make(chan struct{})
+	close(q.popCh)
+	q.popCh = make(chan struct{})
+}
+
+func (q *queue[T]) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
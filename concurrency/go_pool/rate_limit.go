@@ -0,0 +1,93 @@
+package go_pool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket Token bucket admitting rate tasks per second with bursts up to burst, can be called concurrently.
+// Tokens are reserved in call order, so waiting submitters are admitted first come first served
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64   // Tokens added per second
+	burst  float64   // Bucket capacity
+	tokens float64   // Available tokens, negative when reserved ahead of time
+	last   time.Time // Last time tokens were refilled
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) refillLocked(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// allow Take a token if one is available right now
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refillLocked(time.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// reserve Take a token, returns how long to wait until the token is actually available
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refillLocked(time.Now())
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// refund Give back a token taken but not used
+func (b *tokenBucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// wait Take a token, waits until it is available or ctx is done. Returns how long it waited
+func (b *tokenBucket) wait(ctx context.Context) (time.Duration, error) {
+	delay := b.reserve()
+	if delay == 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	start := time.Now()
+	select {
+	case <-timer.C:
+		return time.Since(start), nil
+	case <-ctx.Done():
+		b.refund()
+		return time.Since(start), ctx.Err()
+	}
+}
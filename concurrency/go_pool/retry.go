@@ -0,0 +1,33 @@
+package go_pool
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// Backoff Delay before the retry following the given failed attempt, attempts start at 1
+type Backoff func(attempt int) time.Duration
+
+// ExponentialBackoff Delay doubling every attempt from initial up to max. Each delay is reduced by a random
+// amount of up to jitter times itself, jitter in [0, 1], so tasks failing together do not retry in lockstep
+func ExponentialBackoff(initial, max time.Duration, jitter float64) Backoff {
+	return func(attempt int) time.Duration {
+		delay := initial
+		for n := 1; n < attempt && delay < max; n++ {
+			delay *= 2
+		}
+		if delay > max {
+			delay = max
+		}
+		if jitter > 0 {
+			delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+		}
+		return delay
+	}
+}
+
+// defaultRetryable Retry every failure except panics, a panicking task is likely to panic again
+func defaultRetryable(err error) bool {
+	return !errors.Is(err, ErrTaskPanic)
+}
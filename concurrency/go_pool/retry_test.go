@@ -0,0 +1,25 @@
+package go_pool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	assert := require.New(t)
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond, 0)
+	assert.Equal(10*time.Millisecond, backoff(1))
+	assert.Equal(20*time.Millisecond, backoff(2))
+	assert.Equal(40*time.Millisecond, backoff(3))
+	assert.Equal(50*time.Millisecond, backoff(4))
+	assert.Equal(50*time.Millisecond, backoff(100))
+
+	jittered := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond, 0.5)
+	for attempt := 1; attempt < 10; attempt++ {
+		delay := jittered(attempt)
+		assert.LessOrEqual(delay, backoff(attempt))
+		assert.GreaterOrEqual(delay, backoff(attempt)/2)
+	}
+}
@@ -0,0 +1,156 @@
+package go_pool
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// sharedEntry Pool served by shared workers
+type sharedEntry[T any] struct {
+	pool    *Pool[T]
+	running int // Shared workers currently serving the pool
+}
+
+// sharedWorkers Fixed set of workers serving the pools of a PoolSet, can be called concurrently.
+// A free worker serves pools below their minimum share first, then the pool with the fewest running
+// tasks per weight. Pools without queued tasks are skipped, so their share is borrowed by busy pools
+type sharedWorkers[T any] struct {
+	size int // Number of workers
+
+	mu      sync.Mutex
+	entries []*sharedEntry[T]
+	next    int           // Entry checked first on ties, rotates so equal pools take turns
+	closed  bool          // Workers exit once no pool, paused or not, has queued tasks
+	wakeCh  chan struct{} // Closed and replaced when tasks are queued, wakes up idle workers
+}
+
+func newSharedWorkers[T any](size int) *sharedWorkers[T] {
+	if size <= 0 {
+		log.Fatal("size is less then or equal 0")
+	}
+	s := &sharedWorkers[T]{
+		size:   size,
+		wakeCh: make(chan struct{}),
+	}
+	for i := 0; i < size; i++ {
+		go s.startWorker(i)
+	}
+	return s
+}
+
+func (s *sharedWorkers[T]) add(p *Pool[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, &sharedEntry[T]{pool: p})
+}
+
+// remove Stop serving p, tasks already taken from p are still finished
+func (s *sharedWorkers[T]) remove(p *Pool[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, e := range s.entries {
+		if e.pool == p {
+			s.entries = append(s.entries[:k], s.entries[k+1:]...)
+			return
+		}
+	}
+}
+
+// wake Let idle workers look for queued tasks again
+func (s *sharedWorkers[T]) wake() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.wakeCh)
+	s.wakeCh = make(chan struct{})
+}
+
+// close Workers exit once queued tasks of all pools are finished, tasks of a paused pool keep them
+// waiting until the pool is resumed
+func (s *sharedWorkers[T]) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.wakeCh)
+	s.wakeCh = make(chan struct{})
+}
+
+// pickLocked Entry the next task is taken from, nil if no pool has runnable tasks. s.mu must be held
+func (s *sharedWorkers[T]) pickLocked() *sharedEntry[T] {
+	var best *sharedEntry[T]
+	bestBelowMin, bestLoad := false, 0.0
+	for n := range s.entries {
+		e := s.entries[(s.next+n)%len(s.entries)]
+		if e.running >= e.pool.options.maxSize || !e.pool.queue.ready() {
+			continue
+		}
+		belowMin := e.running < e.pool.options.minShare
+		load := float64(e.running) / float64(e.pool.options.weight)
+		if best == nil || (belowMin && !bestBelowMin) || (belowMin == bestBelowMin && load < bestLoad) {
+			best, bestBelowMin, bestLoad = e, belowMin, load
+		}
+	}
+	if best != nil {
+		s.next = (s.next + 1) % len(s.entries)
+	}
+	return best
+}
+
+// pendingLocked Whether any pool has queued tasks, including paused pools which may still be resumed.
+// s.mu must be held
+func (s *sharedWorkers[T]) pendingLocked() bool {
+	for _, e := range s.entries {
+		if e.pool.queue.len() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *sharedWorkers[T]) startWorker(i int) {
+	for {
+		s.mu.Lock()
+		e := s.pickLocked()
+		if e == nil {
+			if s.closed && !s.pendingLocked() {
+				s.mu.Unlock()
+				return
+			}
+			wake := s.wakeCh
+			s.mu.Unlock()
+			<-wake
+			continue
+		}
+		e.running++
+		s.mu.Unlock()
+
+		e.pool.runShared(i)
+
+		s.mu.Lock()
+		e.running--
+		s.mu.Unlock()
+	}
+}
+
+// runShared Run one queued task on shared worker i, the worker counts as a pool worker meanwhile
+func (p *Pool[T]) runShared(i int) {
+	p.mu.Lock()
+	p.workers++
+	p.mu.Unlock()
+
+	item, ok, _ := p.queue.pop()
+	if ok {
+		p.running.Add(1)
+		p.queueWait.record(time.Since(item.enqueuedAt))
+		p.execute(item.t, i)
+		p.running.Add(-1)
+	}
+
+	p.mu.Lock()
+	p.workers--
+	p.drainedLocked()
+	p.mu.Unlock()
+}
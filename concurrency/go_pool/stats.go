@@ -0,0 +1,145 @@
+package go_pool
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencySamples Number of most recent durations kept for percentile estimation
+const latencySamples = 1024
+
+// Stats Snapshot of pool state and task counters since the pool was created
+type Stats struct {
+	Workers         int           // Live workers
+	Busy            int           // Workers running a task
+	Queued          int           // Tasks waiting in the queue
+	Paused          bool          // Pool is paused, queued tasks wait for resume
+	Submitted       int64         // Tasks accepted by the pool
+	Completed       int64         // Tasks finished, including failed ones
+	Failed          int64         // Tasks whose task callback failed, panicked or timed out after retries
+	Retried         int64         // Task runs repeated by the retry policy
+	Panicked        int64         // Panics recovered from task and done callbacks
+	AvgLatency      time.Duration // Average task callback duration
+	P99Latency      time.Duration // 99th percentile task callback duration over recent tasks
+	AvgQueueWait    time.Duration // Average time tasks spent in the queue
+	Throttled       int64         // Submissions delayed or rejected by the rate limit
+	AvgThrottleWait time.Duration // Average time throttled submissions waited for the rate limit
+}
+
+// latencyRecorder Accumulates durations for average and percentile estimation, can be called concurrently
+type latencyRecorder struct {
+	mu      sync.Mutex
+	count   int64
+	total   time.Duration
+	samples []time.Duration // Ring buffer of the most recent durations
+	next    int
+}
+
+func (r *latencyRecorder) record(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.count++
+	r.total += d
+	if len(r.samples) < latencySamples {
+		r.samples = append(r.samples, d)
+		return
+	}
+	r.samples[r.next] = d
+	r.next = (r.next + 1) % latencySamples
+}
+
+// merge Add the durations recorded by other, used for snapshots over several pools
+func (r *latencyRecorder) merge(other *latencyRecorder) {
+	other.mu.Lock()
+	count, total := other.count, other.total
+	samples := append([]time.Duration{}, other.samples...)
+	other.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.count += count
+	r.total += total
+	r.samples = append(r.samples, samples...)
+}
+
+func (r *latencyRecorder) avg() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.count == 0 {
+		return 0
+	}
+	return r.total / time.Duration(r.count)
+}
+
+func (r *latencyRecorder) percentile(p float64) time.Duration {
+	r.mu.Lock()
+	samples := append([]time.Duration{}, r.samples...)
+	r.mu.Unlock()
+
+	if len(samples) == 0 {
+		return 0
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+	idx := int(float64(len(samples))*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	} else if idx >= len(samples) {
+		idx = len(samples) - 1
+	}
+	return samples[idx]
+}
+
+// Stats Snapshot of pool state and task counters
+func (p *Pool[T]) Stats() Stats {
+	p.mu.Lock()
+	workers := p.workers
+	p.mu.Unlock()
+
+	return Stats{
+		Workers:         workers,
+		Busy:            int(p.running.Load()),
+		Queued:          p.queue.len(),
+		Paused:          p.queue.isPaused(),
+		Submitted:       p.submitted.Load(),
+		Completed:       p.completed.Load(),
+		Failed:          p.failed.Load(),
+		Retried:         p.retried.Load(),
+		Panicked:        p.panics.Load(),
+		AvgLatency:      p.latency.avg(),
+		P99Latency:      p.latency.percentile(0.99),
+		AvgQueueWait:    p.queueWait.avg(),
+		Throttled:       p.throttled.Load(),
+		AvgThrottleWait: p.throttleWait.avg(),
+	}
+}
+
+// reportStats Periodically hand stats to the reporter until the pool has exited, a last report is made on exit
+func (p *Pool[T]) reportStats() {
+	ticker := time.NewTicker(p.options.statsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.options.statsCB(p.Stats())
+		case <-p.doneCh:
+			p.options.statsCB(p.Stats())
+			return
+		}
+	}
+}
+
+// Stats Snapshot of every pool, keyed by pool identifier
+func (p *PoolSet[T]) Stats() map[string]Stats {
+	pools := p.pools()
+	stats := make(map[string]Stats, len(pools))
+	for name, pool := range pools {
+		stats[name] = pool.Stats()
+	}
+	return stats
+}
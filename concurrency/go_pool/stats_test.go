@@ -0,0 +1,74 @@
+package go_pool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolStats(t *testing.T) {
+	assert := require.New(t)
+	reports := make(chan Stats, 100)
+	blockCh := make(chan struct{})
+	p := NewPool(
+		WithSize[TaskForT](2),
+		WithQueueSize[TaskForT](10),
+		WithTaskCB(func(t TaskForT, i int) {
+			<-blockCh
+			time.Sleep(time.Millisecond)
+			if t.i == 0 {
+				panic("fail")
+			}
+		}),
+		WithPanicHandler(func(t TaskForT, worker int, recovered any, stack []byte) {}),
+		WithStatsReporter[TaskForT](5*time.Millisecond, func(stats Stats) {
+			reports <- stats
+		}),
+	)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		assert.NoError(p.Submit(ctx, TaskForT{i: i}))
+	}
+	assert.Eventually(func() bool {
+		stats := p.Stats()
+		return stats.Busy == 2 && stats.Queued == 8
+	}, time.Second, time.Millisecond)
+	close(blockCh)
+	assert.NoError(p.Shutdown(ctx))
+
+	stats := p.Stats()
+	assert.Equal(0, stats.Workers)
+	assert.Equal(0, stats.Queued)
+	assert.Equal(int64(10), stats.Submitted)
+	assert.Equal(int64(10), stats.Completed)
+	assert.Equal(int64(1), stats.Failed)
+	assert.Equal(int64(1), stats.Panicked)
+	assert.GreaterOrEqual(stats.AvgLatency, time.Millisecond)
+	assert.GreaterOrEqual(stats.P99Latency, stats.AvgLatency)
+	assert.Greater(stats.AvgQueueWait, time.Duration(0))
+
+	// Last report is made once the pool has exited
+	var last Stats
+	assert.Eventually(func() bool {
+		select {
+		case last = <-reports:
+		default:
+		}
+		return last.Completed == 10
+	}, time.Second, time.Millisecond)
+}
+
+func TestLatencyRecorder(t *testing.T) {
+	assert := require.New(t)
+	r := latencyRecorder{}
+	assert.Equal(time.Duration(0), r.percentile(0.99))
+	for i := 1; i <= 2*latencySamples; i++ {
+		r.record(time.Duration(i))
+	}
+	assert.Equal(time.Duration(2*latencySamples+1)/2, r.avg())
+	// Only the most recent samples are kept
+	assert.Equal(time.Duration(2*latencySamples), r.percentile(1))
+	assert.Equal(time.Duration(latencySamples+1), r.percentile(0))
+}
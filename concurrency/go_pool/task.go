@@ -0,0 +1,111 @@
+package go_pool
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// execute Run the callbacks of one task and update task counters
+func (p *Pool[T]) execute(t T, i int) {
+	err := p.runWithRetry(t, i)
+	if err != nil {
+		p.failed.Add(1)
+		if p.options.deadLetterCB != nil {
+			p.safeCall(t, i, func(t T, i int) {
+				p.options.deadLetterCB(t, i, err)
+			})
+		}
+	}
+
+	if p.options.doneCB != nil {
+		p.safeCall(t, i, p.options.doneCB)
+	}
+	if p.options.doneErrCB != nil {
+		p.safeCall(t, i, func(t T, i int) {
+			p.options.doneErrCB(t, i, err)
+		})
+	}
+	p.completed.Add(1)
+}
+
+// runWithRetry Run the task until it succeeds, attempts run out or the error is not retryable
+func (p *Pool[T]) runWithRetry(t T, i int) error {
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := p.runTask(t, i)
+		p.latency.record(time.Since(start))
+		if err == nil || attempt >= p.options.maxAttempts || !p.options.retryable(err) {
+			return err
+		}
+
+		p.retried.Add(1)
+		var delay time.Duration
+		if p.options.backoff != nil {
+			delay = p.options.backoff(attempt)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-p.ctx.Done():
+			// Shutdown gave up waiting, no point retrying
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// runTask Run the task callback with a task context, cancelled once the task timeout expires.
+// The worker waits for the callback to return, so a stuck callback keeps holding its worker
+func (p *Pool[T]) runTask(t T, i int) error {
+	if p.options.taskTimeout <= 0 {
+		return p.callTask(p.ctx, t, i)
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.options.taskTimeout)
+	defer cancel()
+	err := p.callTask(ctx, t, i)
+	if ctx.Err() == context.DeadlineExceeded && p.ctx.Err() == nil {
+		return ErrTaskTimeout
+	}
+	return err
+}
+
+func (p *Pool[T]) callTask(ctx context.Context, t T, i int) (err error) {
+	panicErr := p.safeCall(t, i, func(t T, i int) {
+		switch {
+		case p.options.taskErrCB != nil:
+			err = p.options.taskErrCB(ctx, t, i)
+		case p.options.taskCtxCB != nil:
+			p.options.taskCtxCB(ctx, t, i)
+		default:
+			p.options.taskCB(t, i)
+		}
+	})
+	if panicErr != nil {
+		return panicErr
+	}
+	return err
+}
+
+// safeCall Run cb recovering from panic, so a misbehaving callback does not kill the worker
+func (p *Pool[T]) safeCall(t T, i int, cb func(t T, i int)) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		err = fmt.Errorf("%w: %v", ErrTaskPanic, r)
+		p.panics.Add(1)
+		stack := debug.Stack()
+		if p.options.panicCB != nil {
+			p.options.panicCB(t, i, r, stack)
+			return
+		}
+		log.Printf("go_pool worker %d recovered from panic: %v\n%s", i, r, stack)
+	}()
+	cb(t, i)
+	return nil
+}
@@ -68,12 +68,26 @@ type Dynamo[T any] interface {
 	// For unit testing
 	CreateTable(ctx context.Context, input *dynamodb.CreateTableInput) (output *dynamodb.CreateTableOutput, err error)
 	DeleteTable(ctx context.Context, input *dynamodb.DeleteTableInput) (output *dynamodb.DeleteTableOutput, err error)
+	// PoolStats Snapshot of the goroutine pool, used to size PoolSize
+	PoolStats() go_pool.Stats
 	// Exit Close related connection pools
 	Exit()
 }
 
 type eventCB func()
 
+// pageResult Result of a paginated read executed in the pool
+type pageResult[T any] struct {
+	items   []T
+	lastKey any
+}
+
+// itemResult Result of a single record query executed in the pool
+type itemResult[T any] struct {
+	exist bool
+	item  T
+}
+
 type QueryItemCondition struct {
 	Index      string
 	Condition  string
@@ -127,7 +141,7 @@ func NewDynamo[T any](cfg Config) Dynamo[T] {
 		if !ok {
 			log.Fatal("NewDynamo defaultRoundTripper not an *http.Transport")
 		}
-		defaultTransport := *defaultTransportPointer
+		defaultTransport := defaultTransportPointer.Clone()
 		defaultTransport.MaxIdleConns = cfg.PoolSize
 		defaultTransport.MaxIdleConnsPerHost = cfg.PoolSize
 
@@ -139,9 +153,9 @@ func NewDynamo[T any](cfg Config) Dynamo[T] {
 		awsCfg, err := config.LoadDefaultConfig(context.TODO(), func(options *config.LoadOptions) error {
 			// config.WithRegion(cfg.Region)
 			// config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.APIKey, cfg.SecretKey, ""))
-			// config.WithHTTPClient(&http.Client{Transport: &defaultTransport})
+			// config.WithHTTPClient(&http.Client{Transport: defaultTransport})
 			// config.WithLogger(logging.NewStandardLogger(logFile))
-			options.HTTPClient = &http.Client{Transport: &defaultTransport}
+			options.HTTPClient = &http.Client{Transport: defaultTransport}
 			return nil
 			},
         	// Configure retry strategy
@@ -180,6 +194,10 @@ func (d *dynamo[T]) Exit() {
 	d.pool.Exit()
 }
 
+func (d *dynamo[T]) PoolStats() go_pool.Stats {
+	return d.pool.Stats()
+}
+
 func (d *dynamo[T]) scan(ctx context.Context, index string, fromKey any, limit int) (items []T, lastKey any, err error) {
 	var scanFrom map[string]types.AttributeValue
 	var ok bool
@@ -233,100 +251,74 @@ func (d *dynamo[T]) scan(ctx context.Context, index string, fromKey any, limit i
 
 func (d *dynamo[T]) CreateTable(ctx context.Context, input *dynamodb.CreateTableInput) (
 	output *dynamodb.CreateTableOutput, err error) {
-	doneCh := make(chan struct{})
-	d.pool.New(func() {
-		output, err = d.svc.CreateTable(ctx, input)
-		doneCh <- struct{}{}
-	})
-	<-doneCh
-	return
+	return go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (*dynamodb.CreateTableOutput, error) {
+		return d.svc.CreateTable(ctx, input)
+	}).Get(ctx)
 }
 
 func (d *dynamo[T]) DeleteTable(ctx context.Context, input *dynamodb.DeleteTableInput) (
 	output *dynamodb.DeleteTableOutput, err error) {
-	doneCh := make(chan struct{})
-	d.pool.New(func() {
-		output, err = d.svc.DeleteTable(ctx, input)
-		doneCh <- struct{}{}
-	})
-	<-doneCh
-	return
+	return go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (*dynamodb.DeleteTableOutput, error) {
+		return d.svc.DeleteTable(ctx, input)
+	}).Get(ctx)
 }
 
 func (d *dynamo[T]) ScanTable(ctx context.Context, fromKey any, limit int) (items []T, lastKey any, err error) {
-	doneCh := make(chan struct{})
-	d.pool.New(func() {
-		items, lastKey, err = d.scan(ctx, "", fromKey, limit)
-		doneCh <- struct{}{}
-	})
-	<-doneCh
-	return
+	// Scans are served after latency sensitive reads sharing the pool
+	page, err := go_pool.SubmitFuncWithPriority(ctx, d.pool, go_pool.PriorityLow, func(ctx context.Context) (pageResult[T], error) {
+		items, lastKey, err := d.scan(ctx, "", fromKey, limit)
+		return pageResult[T]{items: items, lastKey: lastKey}, err
+	}).Get(ctx)
+	return page.items, page.lastKey, err
 }
 
 func (d *dynamo[T]) ScanIndex(ctx context.Context, index string, fromKey any, limit int) (items []T, lastKey any, err error) {
-	doneCh := make(chan struct{})
-	d.pool.New(func() {
-		items, lastKey, err = d.scan(ctx, index, fromKey, limit)
-		doneCh <- struct{}{}
-	})
-	<-doneCh
-	return
+	// Scans are served after latency sensitive reads sharing the pool
+	page, err := go_pool.SubmitFuncWithPriority(ctx, d.pool, go_pool.PriorityLow, func(ctx context.Context) (pageResult[T], error) {
+		items, lastKey, err := d.scan(ctx, index, fromKey, limit)
+		return pageResult[T]{items: items, lastKey: lastKey}, err
+	}).Get(ctx)
+	return page.items, page.lastKey, err
 }
 
 func (d *dynamo[T]) QueryItem(ctx context.Context, key map[string]any) (exist bool, retItem T, err error) {
-	retCh := make(chan struct {
-		Exist   bool
-		RetItem T
-		Err     error
-	})
-	d.pool.New(func() {
-		var ret struct {
-			Exist   bool
-			RetItem T
-			Err     error
-		}
-		defer func() {
-			retCh <- ret
-		}()
+	return d.getItem(ctx, key, d.cfg.TableName)
+}
 
+// getItem Query a single record of table in the pool
+func (d *dynamo[T]) getItem(ctx context.Context, key map[string]any, table string) (exist bool, retItem T, err error) {
+	ret, err := go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (ret itemResult[T], err error) {
 		keyData, err := attributevalue.MarshalMap(key)
 		if err != nil {
-			ret.Err = errors.Wrap(err, "QueryItem marshal")
-			return
+			return ret, errors.Wrap(err, "QueryItem marshal")
 		}
 		res, err := d.svc.GetItem(ctx, &dynamodb.GetItemInput{
 			ConsistentRead: aws.Bool(true),
-			TableName:      aws.String(d.cfg.TableName),
+			TableName:      aws.String(table),
 			Key:            keyData,
 		})
 		if err != nil {
-			ret.Err = errors.Wrap(err, "QueryItem getItem")
-			return
+			return ret, errors.Wrap(err, "QueryItem getItem")
 		}
 		if res.Item == nil || len(res.Item) == 0 {
-			return
+			return ret, nil
 		}
-		var item T
-		err = attributevalue.UnmarshalMap(res.Item, &item)
+		err = attributevalue.UnmarshalMap(res.Item, &ret.item)
 		if err != nil {
-			ret.Err = errors.Wrap(err, "QueryItem unmarshal")
-			return
+			return ret, errors.Wrap(err, "QueryItem unmarshal")
 		}
-		ret.Exist = true
-		ret.RetItem = item
-	})
-	ret := <-retCh
-	return ret.Exist, ret.RetItem, ret.Err
+		ret.exist = true
+		return ret, nil
+	}).Get(ctx)
+	return ret.exist, ret.item, err
 }
 
 func (d *dynamo[T]) QueryItems(ctx context.Context, index, condition string, expression map[string]any, fromKey any, limit int) (items []T, lastKey any, err error) {
-	doneCh := make(chan struct{})
-	d.pool.New(func() {
-		items, lastKey, err = d.queryItems(ctx, index, condition, expression, fromKey, limit)
-		doneCh <- struct{}{}
-	})
-	<-doneCh
-	return
+	page, err := go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (pageResult[T], error) {
+		items, lastKey, err := d.queryItems(ctx, index, condition, expression, fromKey, limit)
+		return pageResult[T]{items: items, lastKey: lastKey}, err
+	}).Get(ctx)
+	return page.items, page.lastKey, err
 }
 
 func (d *dynamo[T]) queryItems(ctx context.Context, index string, condition string, expression map[string]any, fromKey any, limit int) (items []T, lastKey any, err error) {
@@ -387,59 +379,15 @@ func (d *dynamo[T]) queryItems(ctx context.Context, index string, condition stri
 }
 
 func (d *dynamo[T]) QueryItemWithTable(ctx context.Context, key map[string]any, table string) (exist bool, retItem T, err error) {
-	retCh := make(chan struct {
-		Exist   bool
-		RetItem T
-		Err     error
-	})
-	d.pool.New(func() {
-		var ret struct {
-			Exist   bool
-			RetItem T
-			Err     error
-		}
-		defer func() {
-			retCh <- ret
-		}()
-
-		keyData, err := attributevalue.MarshalMap(key)
-		if err != nil {
-			ret.Err = errors.Wrap(err, "QueryItem marshal")
-			return
-		}
-		res, err := d.svc.GetItem(ctx, &dynamodb.GetItemInput{
-			ConsistentRead: aws.Bool(true),
-			TableName:      &table,
-			Key:            keyData,
-		})
-		if err != nil {
-			ret.Err = errors.Wrap(err, "QueryItem getItem")
-			return
-		}
-		if res.Item == nil || len(res.Item) == 0 {
-			return
-		}
-		var item T
-		err = attributevalue.UnmarshalMap(res.Item, &item)
-		if err != nil {
-			ret.Err = errors.Wrap(err, "QueryItem unmarshal")
-			return
-		}
-		ret.Exist = true
-		ret.RetItem = item
-	})
-	ret := <-retCh
-	return ret.Exist, ret.RetItem, ret.Err
+	return d.getItem(ctx, key, table)
 }
 
 func (d *dynamo[T]) QueryItemsWithTable(ctx context.Context, qcs QueryItemCondition) (items []T, lastKey any, err error) {
-	doneCh := make(chan struct{})
-	d.pool.New(func() {
-		items, lastKey, err = d.queryItemsWithTable(ctx, qcs)
-		doneCh <- struct{}{}
-	})
-	<-doneCh
-	return
+	page, err := go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (pageResult[T], error) {
+		items, lastKey, err := d.queryItemsWithTable(ctx, qcs)
+		return pageResult[T]{items: items, lastKey: lastKey}, err
+	}).Get(ctx)
+	return page.items, page.lastKey, err
 }
 
 func (d *dynamo[T]) queryItemsWithTable(ctx context.Context, qc QueryItemCondition) (items []T, lastKey any, err error) {
@@ -539,15 +487,13 @@ func (d *dynamo[T]) insertItems(ctx context.Context, insertInfos []InsertInfo[T]
 	if len(insertInfos) == 0 {
 		return
 	}
-	errCh := make(chan error)
-	d.pool.New(func() {
+	_, err = go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (struct{}, error) {
 		var requests []types.WriteRequest
 		for i := range insertInfos {
 			var data map[string]types.AttributeValue
 			data, err := attributevalue.MarshalMapWithOptions(insertInfos[i].Item)
 			if err != nil {
-				errCh <- errors.Wrap(err, "InsertItems marshal fail")
-				return
+				return struct{}{}, errors.Wrap(err, "InsertItems marshal fail")
 			}
 			requests = append(requests, types.WriteRequest{
 				PutRequest: &types.PutRequest{
@@ -559,12 +505,11 @@ func (d *dynamo[T]) insertItems(ctx context.Context, insertInfos []InsertInfo[T]
 			d.cfg.TableName: requests,
 		})
 		if err != nil {
-			errCh <- errors.Wrap(err, "InsertItems write fail")
-			return
+			return struct{}{}, errors.Wrap(err, "InsertItems write fail")
 		}
-		errCh <- nil
-	})
-	return <-errCh
+		return struct{}{}, nil
+	}).Get(ctx)
+	return
 }
 
 // insertInfos cannot exceed 100, otherwise dynamodb will report an error
@@ -572,20 +517,18 @@ func (d *dynamo[T]) TxInsertItems(ctx context.Context, insertInfos []TxInsertInf
 	if len(insertInfos) > 100 {
 		return errors.New("insertInfos must have length less than or equal to 100")
 	}
-	errCh := make(chan error)
-	d.pool.New(func() {
+	_, err = go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (struct{}, error) {
 		optsIn := options{}
 		for _, opt := range opts {
 			opt.apply(&optsIn)
 		}
 		err := d.txInsertItems(ctx, insertInfos, optsIn)
 		if err != nil {
-			errCh <- errors.Wrap(err, "TxInsertItems insert fail")
-			return
+			return struct{}{}, errors.Wrap(err, "TxInsertItems insert fail")
 		}
-		errCh <- nil
-	})
-	return <-errCh
+		return struct{}{}, nil
+	}).Get(ctx)
+	return
 }
 
 func (d *dynamo[T]) getInsertTx(item any, conditions Conditions, tableName string) (tx types.TransactWriteItem, err error) {
@@ -652,20 +595,18 @@ func (d *dynamo[T]) TxRawExec(ctx context.Context, insertItems []TxRawInsert, up
 	if len(insertItems)+len(updateItems) > 100 {
 		return errors.New("insertItems and updateItems must have length less than or equal to 100")
 	}
-	errCh := make(chan error)
-	d.pool.New(func() {
+	_, err = go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (struct{}, error) {
 		optsIn := options{}
 		for _, opt := range opts {
 			opt.apply(&optsIn)
 		}
 		err := d.txRawExec(ctx, insertItems, updateItems, optsIn)
 		if err != nil {
-			errCh <- errors.Wrap(err, "TxRawExec insert fail")
-			return
+			return struct{}{}, errors.Wrap(err, "TxRawExec insert fail")
 		}
-		errCh <- nil
-	})
-	return <-errCh
+		return struct{}{}, nil
+	}).Get(ctx)
+	return
 }
 
 func (d *dynamo[T]) getUpdateTx(info UpdateInfo, tableName string) (tx types.TransactWriteItem, err error) {
@@ -741,15 +682,13 @@ func (d *dynamo[T]) DeleteItems(ctx context.Context, keys []map[string]any) (err
 	if len(keys) == 0 {
 		return
 	}
-	errCh := make(chan error)
-	d.pool.New(func() {
+	_, err = go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (struct{}, error) {
 		var requests []types.WriteRequest
 		for i := range keys {
 			var data map[string]types.AttributeValue
-			data, err = attributevalue.MarshalMap(keys[i])
+			data, err := attributevalue.MarshalMap(keys[i])
 			if err != nil {
-				errCh <- errors.Wrap(err, "DeleteItems marshal fail")
-				return
+				return struct{}{}, errors.Wrap(err, "DeleteItems marshal fail")
 			}
 			requests = append(requests, types.WriteRequest{
 				DeleteRequest: &types.DeleteRequest{
@@ -761,17 +700,15 @@ func (d *dynamo[T]) DeleteItems(ctx context.Context, keys []map[string]any) (err
 			d.cfg.TableName: requests,
 		})
 		if err != nil {
-			errCh <- errors.Wrap(err, "DeleteItems write fail")
-			return
+			return struct{}{}, errors.Wrap(err, "DeleteItems write fail")
 		}
-		errCh <- nil
-	})
-	return <-errCh
+		return struct{}{}, nil
+	}).Get(ctx)
+	return
 }
 
 func (d *dynamo[T]) UpdateItem(ctx context.Context, update UpdateInfo) (err error) {
-	errCh := make(chan error)
-	d.pool.New(func() {
+	_, err = go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (struct{}, error) {
 		key, sets, removes := update.Key, update.Sets, update.Removes
 		names, values := make(map[string]string), make(map[string]any)
 
@@ -780,15 +717,13 @@ func (d *dynamo[T]) UpdateItem(ctx context.Context, update UpdateInfo) (err erro
 
 		keyData, err := attributevalue.MarshalMap(key)
 		if err != nil {
-			errCh <- errors.Wrap(err, "UpdateItem marshal key")
-			return
+			return struct{}{}, errors.Wrap(err, "UpdateItem marshal key")
 		}
 		var valueData map[string]types.AttributeValue
 		if len(values) > 0 {
 			valueData, err = attributevalue.MarshalMap(values)
 			if err != nil {
-				errCh <- errors.Wrap(err, "UpdateItem marshal values")
-				return
+				return struct{}{}, errors.Wrap(err, "UpdateItem marshal values")
 			}
 		}
 
@@ -807,12 +742,11 @@ func (d *dynamo[T]) UpdateItem(ctx context.Context, update UpdateInfo) (err erro
 		}
 		_, err = d.svc.UpdateItem(ctx, updateInput)
 		if err != nil {
-			errCh <- errors.Wrap(err, "UpdateItem update item")
-			return
+			return struct{}{}, errors.Wrap(err, "UpdateItem update item")
 		}
-		errCh <- nil
-	})
-	return <-errCh
+		return struct{}{}, nil
+	}).Get(ctx)
+	return
 }
 
 func (d *dynamo[T]) UpdateItems(ctx context.Context, updates []UpdateInfo) (succKeys []map[string]any, err error) {
@@ -863,20 +797,18 @@ func (d *dynamo[T]) TxUpdateItems(ctx context.Context, updates []UpdateInfo, opt
 	if len(updates) > 100 {
 		return errors.New("updates must have length less than or equal to 100")
 	}
-	errCh := make(chan error)
-	d.pool.New(func() {
+	_, err = go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (struct{}, error) {
 		optsIn := options{}
 		for _, opt := range opts {
 			opt.apply(&optsIn)
 		}
 		err := d.txUpdateItems(ctx, updates, optsIn)
 		if err != nil {
-			errCh <- errors.Wrap(err, "TxUpdateItems update fail")
-			return
+			return struct{}{}, errors.Wrap(err, "TxUpdateItems update fail")
 		}
-		errCh <- nil
-	})
-	return <-errCh
+		return struct{}{}, nil
+	}).Get(ctx)
+	return
 }
 
 func (d *dynamo[T]) txUpdateItems(ctx context.Context, updates []UpdateInfo, opts options) (err error) {
@@ -25,6 +25,8 @@ type Streams interface {
 	GetShardIterator(ctx context.Context, iteratorPut ShardIterator) (shardIterator any, err error)
 	GetRecords(ctx context.Context, shardIterator any, limit int) (items []any, err error)
 
+	// PoolStats Snapshot of the goroutine pool, used to size PoolSize
+	PoolStats() go_pool.Stats
 	// Exit Close related connection pools
 	Exit()
 }
@@ -53,7 +55,7 @@ func NewStreams(cfg Config) Streams {
 		if !ok {
 			log.Fatal("NewStreams defaultRoundTripper not an *http.Transport")
 		}
-		defaultTransport := *defaultTransportPointer
+		defaultTransport := defaultTransportPointer.Clone()
 		defaultTransport.MaxIdleConns = cfg.PoolSize
 		defaultTransport.MaxIdleConnsPerHost = cfg.PoolSize
 
@@ -63,7 +65,7 @@ func NewStreams(cfg Config) Streams {
 		}
 
 		awsCfg, err := config.LoadDefaultConfig(context.TODO(), func(options *config.LoadOptions) error {
-			options.HTTPClient = &http.Client{Transport: &defaultTransport}
+			options.HTTPClient = &http.Client{Transport: defaultTransport}
 			return nil
 		})
 		if err != nil {
@@ -93,6 +95,10 @@ func (d *streams) Exit() {
 	d.pool.Exit()
 }
 
+func (d *streams) PoolStats() go_pool.Stats {
+	return d.pool.Stats()
+}
+
 func (d *streams) listStreams(ctx context.Context, fromKey any, limit int) (items []Stream, lastKey any, err error) {
 	var listFrom string
 	var ok bool
@@ -145,13 +151,11 @@ func (d *streams) listStreams(ctx context.Context, fromKey any, limit int) (item
 }
 
 func (d *streams) ListStreams(ctx context.Context, fromKey any, limit int) (items []Stream, lastKey any, err error) {
-	doneCh := make(chan struct{})
-	d.pool.New(func() {
-		items, lastKey, err = d.listStreams(ctx, fromKey, limit)
-		doneCh <- struct{}{}
-	})
-	<-doneCh
-	return
+	page, err := go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (pageResult[Stream], error) {
+		items, lastKey, err := d.listStreams(ctx, fromKey, limit)
+		return pageResult[Stream]{items: items, lastKey: lastKey}, err
+	}).Get(ctx)
+	return page.items, page.lastKey, err
 }
 
 func (d *streams) describeStream(ctx context.Context, streamArn string, fromKey any, limit int) (items []StreamDescription, lastKey any, err error) {
@@ -214,13 +218,11 @@ func (d *streams) describeStream(ctx context.Context, streamArn string, fromKey
 }
 
 func (d *streams) DescribeStream(ctx context.Context, streamArn string, fromKey any, limit int) (items []StreamDescription, lastKey any, err error) {
-	doneCh := make(chan struct{})
-	d.pool.New(func() {
-		items, lastKey, err = d.describeStream(ctx, streamArn, fromKey, limit)
-		doneCh <- struct{}{}
-	})
-	<-doneCh
-	return
+	page, err := go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (pageResult[StreamDescription], error) {
+		items, lastKey, err := d.describeStream(ctx, streamArn, fromKey, limit)
+		return pageResult[StreamDescription]{items: items, lastKey: lastKey}, err
+	}).Get(ctx)
+	return page.items, page.lastKey, err
 }
 
 func (d *streams) getShardIterator(ctx context.Context, iteratorPut ShardIterator) (shardIterator any, err error) {
@@ -243,13 +245,9 @@ func (d *streams) getShardIterator(ctx context.Context, iteratorPut ShardIterato
 }
 
 func (d *streams) GetShardIterator(ctx context.Context, iteratorPut ShardIterator) (shardIterator any, err error) {
-	doneCh := make(chan struct{})
-	d.pool.New(func() {
-		shardIterator, err = d.getShardIterator(ctx, iteratorPut)
-		doneCh <- struct{}{}
-	})
-	<-doneCh
-	return
+	return go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) (any, error) {
+		return d.getShardIterator(ctx, iteratorPut)
+	}).Get(ctx)
 }
 
 func (d *streams) getRecords(ctx context.Context, shardIterator any, limit int) (items []any, err error) {
@@ -289,11 +287,7 @@ func (d *streams) getRecords(ctx context.Context, shardIterator any, limit int)
 }
 
 func (d *streams) GetRecords(ctx context.Context, shardIterator any, limit int) (items []any, err error) {
-	doneCh := make(chan struct{})
-	d.pool.New(func() {
-		items, err = d.getRecords(ctx, shardIterator, limit)
-		doneCh <- struct{}{}
-	})
-	<-doneCh
-	return
+	return go_pool.SubmitFunc(ctx, d.pool, func(ctx context.Context) ([]any, error) {
+		return d.getRecords(ctx, shardIterator, limit)
+	}).Get(ctx)
 }
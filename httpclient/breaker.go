@@ -0,0 +1,235 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCircuitOpen Request rejected without being sent because the circuit of its resource is open
+var ErrCircuitOpen = errors.New("httpclient: circuit open")
+
+// CircuitState State of the circuit of a resource
+type CircuitState int
+
+const (
+	// CircuitClosed Requests pass, outcomes are counted
+	CircuitClosed CircuitState = iota
+	// CircuitOpen Requests are rejected until the cool-down ends
+	CircuitOpen
+	// CircuitHalfOpen A few probe requests pass, their outcomes close or reopen the circuit
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerConfig When a circuit opens and how it recovers
+type BreakerConfig struct {
+	Window           time.Duration // Period outcomes are counted over while closed, defaults to 10s
+	MinRequests      int           // Requests in a window before the circuit may open, defaults to 10
+	FailureRatio     float64       // Ratio of failed requests opening the circuit, defaults to 0.5
+	SlowCallDuration time.Duration // Requests taking longer are slow, slow calls are not counted if 0
+	SlowCallRatio    float64       // Ratio of slow requests opening the circuit, defaults to 1
+	CoolDown         time.Duration // How long the circuit stays open, defaults to 5s
+	HalfOpenRequests int           // Probe requests passed while half-open, all must succeed to close, defaults to 1
+}
+
+// CircuitBreaker Circuit per resource, a resource is the host and path of the request URL.
+// Network errors and 5xx responses are failures. Can be shared by clients and called concurrently.
+// Closed circuits idle for more than a window are evicted, so paths carrying IDs do not pile up circuits
+type CircuitBreaker struct {
+	config     BreakerConfig
+	mu         sync.Mutex
+	circuits   map[string]*circuit
+	generation uint64    // Last generation handed to a circuit, unique over evicted and recreated circuits
+	sweepAt    time.Time // Next time idle circuits are evicted
+}
+
+type circuit struct {
+	state      CircuitState
+	generation uint64    // Changes with every state change or new window, outcomes of an older generation are ignored
+	expiry     time.Time // End of the window when closed, end of the cool-down when open
+	requests   int
+	failures   int
+	slow       int
+	probes     int // Probe requests passed while half-open
+}
+
+// NewCircuitBreaker Circuit breaker configured by config
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	if config.Window <= 0 {
+		config.Window = 10 * time.Second
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.FailureRatio <= 0 {
+		config.FailureRatio = 0.5
+	}
+	if config.SlowCallRatio <= 0 {
+		config.SlowCallRatio = 1
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = 5 * time.Second
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+	return &CircuitBreaker{
+		config:   config,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// State Current state of the circuit of resource
+func (b *CircuitBreaker) State(resource string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[resource]
+	if !ok {
+		return CircuitClosed
+	}
+	b.advance(c, time.Now())
+	return c.state
+}
+
+// advance Move to the next state or window once the current one expires
+func (b *CircuitBreaker) advance(c *circuit, now time.Time) {
+	switch {
+	case c.state == CircuitClosed && now.After(c.expiry):
+		b.setState(c, CircuitClosed, now)
+	case c.state == CircuitOpen && now.After(c.expiry):
+		b.setState(c, CircuitHalfOpen, now)
+	}
+}
+
+func (b *CircuitBreaker) setState(c *circuit, state CircuitState, now time.Time) {
+	c.state = state
+	b.generation++
+	c.generation = b.generation
+	c.requests, c.failures, c.slow, c.probes = 0, 0, 0, 0
+	switch state {
+	case CircuitClosed:
+		c.expiry = now.Add(b.config.Window)
+	case CircuitOpen:
+		c.expiry = now.Add(b.config.CoolDown)
+	default:
+		c.expiry = time.Time{}
+	}
+}
+
+// allow Returns the generation to report the outcome with, or ErrCircuitOpen
+func (b *CircuitBreaker) allow(resource string) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.sweep(now)
+	c, ok := b.circuits[resource]
+	if !ok {
+		c = &circuit{}
+		b.setState(c, CircuitClosed, now)
+		b.circuits[resource] = c
+	}
+	b.advance(c, now)
+
+	switch c.state {
+	case CircuitOpen:
+		return 0, errors.Wrap(ErrCircuitOpen, resource)
+	case CircuitHalfOpen:
+		if c.probes >= b.config.HalfOpenRequests {
+			return 0, errors.Wrap(ErrCircuitOpen, resource)
+		}
+		c.probes++
+	}
+	return c.generation, nil
+}
+
+// sweep Evict closed circuits without requests for a whole window after their last one, at most once per window.
+// Their counts would be reset on the next request anyway
+func (b *CircuitBreaker) sweep(now time.Time) {
+	if now.Before(b.sweepAt) {
+		return
+	}
+	b.sweepAt = now.Add(b.config.Window)
+	for resource, c := range b.circuits {
+		if c.state == CircuitClosed && now.After(c.expiry.Add(b.config.Window)) {
+			delete(b.circuits, resource)
+		}
+	}
+}
+
+// done Report the outcome of a request allowed in generation
+func (b *CircuitBreaker) done(resource string, generation uint64, failed bool, cost time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[resource]
+	if c == nil || c.generation != generation {
+		return
+	}
+	now := time.Now()
+	slow := b.config.SlowCallDuration > 0 && cost > b.config.SlowCallDuration
+
+	if c.state == CircuitHalfOpen {
+		if failed || slow {
+			b.setState(c, CircuitOpen, now)
+			return
+		}
+		c.requests++
+		if c.requests >= b.config.HalfOpenRequests {
+			b.setState(c, CircuitClosed, now)
+		}
+		return
+	}
+
+	c.requests++
+	if failed {
+		c.failures++
+	}
+	if slow {
+		c.slow++
+	}
+	if c.requests < b.config.MinRequests {
+		return
+	}
+	requests := float64(c.requests)
+	if float64(c.failures)/requests >= b.config.FailureRatio ||
+		(b.config.SlowCallDuration > 0 && float64(c.slow)/requests >= b.config.SlowCallRatio) {
+		b.setState(c, CircuitOpen, now)
+	}
+}
+
+// isFailure Whether the outcome of a request counts against its circuit
+func isFailure(response *http.Response, err error) bool {
+	if err != nil {
+		// The caller giving up says nothing about the dependency
+		return !errors.Is(err, context.Canceled)
+	}
+	return response.StatusCode >= http.StatusInternalServerError
+}
+
+type breakerOption struct {
+	breaker *CircuitBreaker
+}
+
+func (b breakerOption) apply(o *httpClientOptions) {
+	o.breaker = b.breaker
+}
+
+// WithCircuitBreaker Send requests through breaker, requests to a resource whose circuit is open fail with
+// ErrCircuitOpen. Pass the same breaker to every request, e.g. as an option of NewClient
+func WithCircuitBreaker(breaker *CircuitBreaker) Option {
+	return breakerOption{breaker: breaker}
+}
@@ -0,0 +1,179 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// report Allow a request on resource and report its outcome
+func report(b *CircuitBreaker, resource string, failed bool, cost time.Duration) error {
+	generation, err := b.allow(resource)
+	if err != nil {
+		return err
+	}
+	b.done(resource, generation, failed, cost)
+	return nil
+}
+
+func TestCircuitBreakerFailureRatio(t *testing.T) {
+	assert := require.New(t)
+	b := NewCircuitBreaker(BreakerConfig{MinRequests: 4, FailureRatio: 0.5, CoolDown: 30 * time.Millisecond,
+		HalfOpenRequests: 2})
+
+	// Not enough requests to judge yet
+	for i := 0; i < 3; i++ {
+		assert.NoError(report(b, "r", true, 0))
+	}
+	assert.Equal(CircuitClosed, b.State("r"))
+	assert.NoError(report(b, "r", false, 0))
+	assert.Equal(CircuitOpen, b.State("r"))
+	assert.ErrorIs(report(b, "r", false, 0), ErrCircuitOpen)
+	// Circuits are per resource
+	assert.Equal(CircuitClosed, b.State("other"))
+	assert.NoError(report(b, "other", false, 0))
+
+	// Cool-down over, a limited number of probes pass
+	time.Sleep(40 * time.Millisecond)
+	assert.Equal(CircuitHalfOpen, b.State("r"))
+	g1, err := b.allow("r")
+	assert.NoError(err)
+	g2, err := b.allow("r")
+	assert.NoError(err)
+	_, err = b.allow("r")
+	assert.ErrorIs(err, ErrCircuitOpen)
+	b.done("r", g1, false, 0)
+	assert.Equal(CircuitHalfOpen, b.State("r"))
+	b.done("r", g2, false, 0)
+	assert.Equal(CircuitClosed, b.State("r"))
+
+	// A failed probe opens the circuit again
+	for i := 0; i < 4; i++ {
+		assert.NoError(report(b, "r", true, 0))
+	}
+	assert.Equal(CircuitOpen, b.State("r"))
+	time.Sleep(40 * time.Millisecond)
+	assert.NoError(report(b, "r", true, 0))
+	assert.Equal(CircuitOpen, b.State("r"))
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	assert := require.New(t)
+	b := NewCircuitBreaker(BreakerConfig{Window: 30 * time.Millisecond, MinRequests: 2})
+	assert.NoError(report(b, "r", true, 0))
+	// Counts start over with each window
+	time.Sleep(40 * time.Millisecond)
+	assert.NoError(report(b, "r", true, 0))
+	assert.Equal(CircuitClosed, b.State("r"))
+	assert.NoError(report(b, "r", true, 0))
+	assert.Equal(CircuitOpen, b.State("r"))
+}
+
+func TestCircuitBreakerSlowCalls(t *testing.T) {
+	assert := require.New(t)
+	b := NewCircuitBreaker(BreakerConfig{MinRequests: 2, SlowCallDuration: 10 * time.Millisecond, SlowCallRatio: 0.5})
+	assert.NoError(report(b, "r", false, time.Millisecond))
+	assert.NoError(report(b, "r", false, time.Millisecond))
+	assert.Equal(CircuitClosed, b.State("r"))
+	assert.NoError(report(b, "r", false, 20*time.Millisecond))
+	assert.NoError(report(b, "r", false, 20*time.Millisecond))
+	assert.Equal(CircuitOpen, b.State("r"))
+
+	// Slow calls are not counted without SlowCallDuration
+	b = NewCircuitBreaker(BreakerConfig{MinRequests: 2})
+	assert.NoError(report(b, "r", false, time.Hour))
+	assert.NoError(report(b, "r", false, time.Hour))
+	assert.Equal(CircuitClosed, b.State("r"))
+}
+
+func TestCircuitBreakerGeneration(t *testing.T) {
+	assert := require.New(t)
+	b := NewCircuitBreaker(BreakerConfig{MinRequests: 1, CoolDown: 20 * time.Millisecond})
+	stale, err := b.allow("r")
+	assert.NoError(err)
+	assert.NoError(report(b, "r", true, 0))
+	assert.Equal(CircuitOpen, b.State("r"))
+
+	// Outcome of a request allowed before the circuit opened does not close the half-open circuit
+	time.Sleep(30 * time.Millisecond)
+	probe, err := b.allow("r")
+	assert.NoError(err)
+	b.done("r", stale, false, 0)
+	assert.Equal(CircuitHalfOpen, b.State("r"))
+	b.done("r", probe, false, 0)
+	assert.Equal(CircuitClosed, b.State("r"))
+}
+
+func TestCircuitBreakerEviction(t *testing.T) {
+	assert := require.New(t)
+	b := NewCircuitBreaker(BreakerConfig{Window: 10 * time.Millisecond, MinRequests: 1, CoolDown: time.Minute})
+	for i := 0; i < 100; i++ {
+		assert.NoError(report(b, fmt.Sprintf("/users/%d", i), false, 0))
+	}
+	assert.NoError(report(b, "down", true, 0))
+	stale, err := b.allow("/users/0")
+	assert.NoError(err)
+	assert.Len(b.circuits, 101)
+
+	// Idle closed circuits are evicted, open ones are kept
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(report(b, "/users/0", false, 0))
+	assert.Len(b.circuits, 2)
+	assert.Equal(CircuitOpen, b.State("down"))
+	// Outcome of a request allowed before eviction is not counted by the new circuit
+	b.done("/users/0", stale, true, 0)
+	assert.Equal(CircuitClosed, b.State("/users/0"))
+}
+
+func TestIsFailure(t *testing.T) {
+	assert := require.New(t)
+	assert.True(isFailure(nil, errors.New("connection refused")))
+	assert.True(isFailure(nil, context.DeadlineExceeded))
+	assert.False(isFailure(nil, context.Canceled))
+	assert.True(isFailure(&http.Response{StatusCode: http.StatusBadGateway}, nil))
+	assert.False(isFailure(&http.Response{StatusCode: http.StatusNotFound}, nil))
+	assert.False(isFailure(&http.Response{StatusCode: http.StatusOK}, nil))
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	assert := require.New(t)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	b := NewCircuitBreaker(BreakerConfig{MinRequests: 2})
+	c, err := NewClient(HTTPCliConfig{Address: srv.URL}, WithCircuitBreaker(b))
+	assert.NoError(err)
+	ctx := context.Background()
+
+	assert.Error(c.Get(ctx, "/down", nil))
+	assert.Error(c.Get(ctx, "/down", nil))
+	// Rejected without reaching the server
+	assert.ErrorIs(c.Get(ctx, "/down", nil), ErrCircuitOpen)
+	assert.Equal(int32(2), calls.Load())
+	assert.Equal(CircuitOpen, b.State(parseUrlResource(srv.URL+"/down")))
+	// Other resources of the same host are unaffected
+	assert.NoError(c.Get(ctx, "/up?x=1", nil))
+	assert.Equal(CircuitClosed, b.State(parseUrlResource(srv.URL+"/up")))
+
+	// Retrying stops once the circuit opens
+	calls.Store(0)
+	b = NewCircuitBreaker(BreakerConfig{MinRequests: 2})
+	err = c.Get(ctx, "/down", nil, WithCircuitBreaker(b),
+		WithRetry(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond, RetryOn: []int{http.StatusInternalServerError}}))
+	assert.ErrorIs(err, ErrCircuitOpen)
+	assert.Equal(int32(2), calls.Load())
+}
@@ -0,0 +1,391 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTimeout             = 3 * time.Second
+	defaultDialTimeout         = 30 * time.Second
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 100
+)
+
+// Client HTTP client sharing one connection pool over all requests, can be called concurrently.
+// Requests through a proxy share a connection pool per proxy
+type Client struct {
+	config    HTTPCliConfig
+	opts      []Option // Applied to every request before the per call options
+	timeout   time.Duration
+	transport *http.Transport
+
+	mu              sync.Mutex
+	proxyTransports map[string]*http.Transport // Keyed by proxy URL
+}
+
+var defaultClient atomic.Pointer[Client]
+
+func init() {
+	c, err := NewClient(HTTPCliConfig{}, WithMiddleware(LogMiddleware(nil, time.Duration(TimeoutMS)*time.Millisecond)))
+	if err != nil {
+		panic(err)
+	}
+	defaultClient.Store(c)
+}
+
+// Default Client used by the package level functions
+func Default() *Client {
+	return defaultClient.Load()
+}
+
+// SetDefault Replace the client used by the package level functions
+func SetDefault(c *Client) {
+	defaultClient.Store(c)
+}
+
+// NewClient Client configured by config, opts apply to every request it sends
+func NewClient(config HTTPCliConfig, opts ...Option) (*Client, error) {
+	tlsConfig, err := newTLSConfig(config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   msOrDefault(config.DialTimeoutMS, defaultDialTimeout),
+		KeepAlive: 30 * time.Second,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.TLSClientConfig = tlsConfig
+	transport.IdleConnTimeout = msOrDefault(config.IdleConnTimeoutMS, defaultIdleConnTimeout)
+	transport.MaxIdleConns = intOrDefault(config.MaxIdleConns, defaultMaxIdleConns)
+	transport.MaxIdleConnsPerHost = intOrDefault(config.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost)
+	transport.MaxConnsPerHost = config.MaxConnsPerHost
+
+	return &Client{
+		config:          config,
+		opts:            opts,
+		timeout:         msOrDefault(config.TimeoutMS, defaultTimeout),
+		transport:       transport,
+		proxyTransports: make(map[string]*http.Transport),
+	}, nil
+}
+
+func newTLSConfig(config TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewClient read ca file")
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("NewClient no certificate in ca file %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewClient load client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func msOrDefault(ms int64, def time.Duration) time.Duration {
+	if ms <= 0 {
+		return def
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+func intOrDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// resolve Prefix the configured address to a URL without scheme and host
+func (c *Client) resolve(urlStr string) string {
+	if c.config.Address == "" || strings.Contains(urlStr, "://") {
+		return urlStr
+	}
+	base := c.config.Address
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(urlStr, "/")
+}
+
+// httpClient Client over the shared transport, or the shared transport of a randomly chosen proxy
+func (c *Client) httpClient(opt httpClientOptions) *http.Client {
+	if len(opt.proxies) == 0 {
+		return &http.Client{Transport: c.transport}
+	}
+
+	// Randomly select a proxy from the proxy list
+	rndIdx := time.Now().UnixNano() % int64(len(opt.proxies))
+	proxy := opt.proxies[rndIdx]
+	proxyUrl, err := url.Parse(proxy)
+	if err != nil {
+		return &http.Client{Transport: c.transport}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	transport, ok := c.proxyTransports[proxy]
+	if !ok {
+		transport = c.transport.Clone()
+		transport.Proxy = http.ProxyURL(proxyUrl)
+		c.proxyTransports[proxy] = transport
+	}
+	return &http.Client{Transport: transport}
+}
+
+// CloseIdleConnections Close idle connections of the shared connection pools
+func (c *Client) CloseIdleConnections() {
+	c.transport.CloseIdleConnections()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, transport := range c.proxyTransports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// Get Send a GET request and decode the response into out
+func (c *Client) Get(ctx context.Context, urlStr string, out interface{}, opts ...Option) error {
+	return c.Do(ctx, Request{Method: http.MethodGet, URL: urlStr}, out, opts...)
+}
+
+// Post Send a POST request with body and decode the response into out
+func (c *Client) Post(ctx context.Context, urlStr string, header http.Header, body []byte, out interface{},
+	opts ...Option) error {
+	return c.Do(ctx, Request{Method: http.MethodPost, URL: urlStr, Header: header, Body: RawBody(body, "")}, out, opts...)
+}
+
+// Put Send a PUT request with body and decode the response into out
+func (c *Client) Put(ctx context.Context, urlStr string, header http.Header, body []byte, out interface{},
+	opts ...Option) error {
+	return c.Do(ctx, Request{Method: http.MethodPut, URL: urlStr, Header: header, Body: RawBody(body, "")}, out, opts...)
+}
+
+// Patch Send a PATCH request with body and decode the response into out
+func (c *Client) Patch(ctx context.Context, urlStr string, header http.Header, body []byte, out interface{},
+	opts ...Option) error {
+	return c.Do(ctx, Request{Method: http.MethodPatch, URL: urlStr, Header: header, Body: RawBody(body, "")}, out, opts...)
+}
+
+// Delete Send a DELETE request and decode the response into out
+func (c *Client) Delete(ctx context.Context, urlStr string, header http.Header, out interface{}, opts ...Option) error {
+	return c.Do(ctx, Request{Method: http.MethodDelete, URL: urlStr, Header: header}, out, opts...)
+}
+
+// Head Send a HEAD request and return the response header
+func (c *Client) Head(ctx context.Context, urlStr string, header http.Header, opts ...Option) (http.Header, error) {
+	return c.do(ctx, Request{Method: http.MethodHead, URL: urlStr, Header: header}, nil, opts)
+}
+
+// Do Send req and decode the response into out with the decoder of WithDecoder, or by the response Content-Type.
+// Numbers decoded into an interface are json.Number. out may be *struct{} or nil to ignore the body,
+// or *io.Reader or *io.ReadCloser to stream it, the caller must close the stream.
+// Without a ctx deadline the client timeout of a stream only covers waiting for the response headers
+func (c *Client) Do(ctx context.Context, req Request, out interface{}, opts ...Option) error {
+	_, err := c.do(ctx, req, out, opts)
+	return err
+}
+
+// newRequest Build the http request of req with the encoded body, name prefixes errors
+func (c *Client) newRequest(ctx context.Context, name string, req Request, body []byte,
+	contentType string) (*http.Request, error) {
+	urlStr := c.resolve(req.URL)
+	if len(req.Query) > 0 {
+		sep := "?"
+		if strings.Contains(urlStr, "?") {
+			sep = "&"
+		}
+		urlStr += sep + req.Query.Encode()
+	}
+
+	var bodyReader io.Reader
+	if req.Body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, urlStr, bodyReader)
+	if err != nil {
+		return nil, errors.Wrap(err, name+" new req")
+	}
+	if req.Header != nil {
+		httpReq.Header = req.Header.Clone()
+	}
+	if contentType != "" && httpReq.Header.Get("Content-Type") == "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	return httpReq, nil
+}
+
+// send Send req through the circuit breaker, retrying according to the retry policy.
+// Returns the response of the last attempt
+func (c *Client) send(ctx context.Context, cli *http.Client, name string, req Request,
+	opt httpClientOptions) (response *http.Response, attempts int, err error) {
+	retry := opt.retry
+	var body []byte
+	contentType := ""
+	if req.Body != nil {
+		// Encoded once, every attempt sends the same bytes
+		if body, contentType, err = req.Body.Encode(); err != nil {
+			return nil, 0, errors.Wrap(err, name+" encode body")
+		}
+	}
+
+	for attempts = 1; ; attempts++ {
+		httpReq, err := c.newRequest(ctx, name, req, body, contentType)
+		if err != nil {
+			return nil, attempts, err
+		}
+		response, err = c.roundTrip(cli, httpReq, opt)
+		if err != nil {
+			err = errors.Wrap(err, name+" send http")
+		}
+		if errors.Is(err, ErrCircuitOpen) {
+			return nil, attempts, err
+		}
+		if retry == nil || attempts >= retry.MaxAttempts || !retry.retryable(ctx, req.Method, response, err) {
+			return response, attempts, err
+		}
+
+		delay := retry.backoff(attempts, response)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// No time left for another attempt
+			return response, attempts, err
+		}
+		if response != nil {
+			// Let the connection be reused
+			_, _ = io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+		if !sleep(ctx, delay) {
+			return nil, attempts, errors.Wrap(ctx.Err(), name+" wait retry")
+		}
+	}
+}
+
+// roundTrip Send httpReq through the middlewares, unless the circuit of its resource is open.
+// The outcome is reported to the circuit breaker
+func (c *Client) roundTrip(cli *http.Client, httpReq *http.Request, opt httpClientOptions) (*http.Response, error) {
+	send := cli.Do
+	if breaker := opt.breaker; breaker != nil {
+		send = func(httpReq *http.Request) (*http.Response, error) {
+			resource := parseUrlResource(httpReq.URL.String())
+			generation, err := breaker.allow(resource)
+			if err != nil {
+				return nil, err
+			}
+			tp := time.Now()
+			response, err := cli.Do(httpReq)
+			breaker.done(resource, generation, isFailure(response, err), time.Since(tp))
+			return response, err
+		}
+	}
+	return chain(send, opt.middlewares)(httpReq)
+}
+
+func (c *Client) do(ctx context.Context, req Request, out interface{}, opts []Option) (header http.Header, err error) {
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	// Error prefix like Get, Post
+	name := req.Method[:1] + strings.ToLower(req.Method[1:])
+
+	var response *http.Response
+	attempts := 0
+	defer func() {
+		if err != nil && attempts > 1 {
+			err = &RetryError{Attempts: attempts, Err: err}
+		}
+	}()
+
+	var cancel, headersArrived func()
+	if isStream(out) {
+		// The default timeout must not cut off a long streamed body
+		ctx, cancel, headersArrived = handleHeaderDeadline(ctx, c.timeout)
+	} else {
+		ctx, cancel = handleCtxDeadline(ctx, c.timeout)
+	}
+	streamed := false
+	defer func() {
+		// A streamed body releases ctx when closed
+		if !streamed {
+			cancel()
+		}
+	}()
+
+	opt := httpClientOptions{}
+	for _, o := range append(append([]Option{}, c.opts...), opts...) {
+		o.apply(&opt)
+	}
+	cli := c.httpClient(opt)
+
+	response, attempts, err = c.send(ctx, cli, name, req, opt)
+	if headersArrived != nil {
+		headersArrived()
+	}
+	if err != nil {
+		return
+	}
+	header = response.Header
+	success := opt.success(response.StatusCode)
+	if success && isStream(out) {
+		setStream(out, &streamBody{ReadCloser: response.Body, cancel: cancel})
+		streamed = true
+		return
+	}
+	defer response.Body.Close()
+
+	if !success {
+		err = newHTTPError(response)
+		return
+	}
+	if isIgnored(out) || response.StatusCode == http.StatusNoContent {
+		// Let the connection be reused
+		_, _ = io.Copy(io.Discard, response.Body)
+		return
+	}
+
+	responseData, err := io.ReadAll(response.Body)
+	if err != nil {
+		err = errors.Wrap(err, name+" read response")
+		return
+	}
+
+	err = decodeBody(responseData, response.Header.Get("Content-Type"), out, opt.decoder)
+	if err != nil {
+		err = errors.Wrap(err, fmt.Sprintf("%s unmarshal response %s", name, string(responseData)))
+		return
+	}
+	return
+}
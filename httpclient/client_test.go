@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientResolve(t *testing.T) {
+	assert := require.New(t)
+	c, err := NewClient(HTTPCliConfig{})
+	assert.NoError(err)
+	assert.Equal("/v1/users", c.resolve("/v1/users"))
+
+	c, err = NewClient(HTTPCliConfig{Address: "api.example.com:8080/"})
+	assert.NoError(err)
+	assert.Equal("http://api.example.com:8080/v1/users", c.resolve("/v1/users"))
+	assert.Equal("http://api.example.com:8080/v1/users", c.resolve("v1/users"))
+	assert.Equal("https://other.example.com/x", c.resolve("https://other.example.com/x"))
+
+	c, err = NewClient(HTTPCliConfig{Address: "https://api.example.com"})
+	assert.NoError(err)
+	assert.Equal("https://api.example.com/v1", c.resolve("/v1"))
+}
+
+func TestNewClientConfig(t *testing.T) {
+	assert := require.New(t)
+	c, err := NewClient(HTTPCliConfig{MaxIdleConnsPerHost: 7, MaxConnsPerHost: 9, TimeoutMS: 1500})
+	assert.NoError(err)
+	assert.Equal(7, c.transport.MaxIdleConnsPerHost)
+	assert.Equal(defaultMaxIdleConns, c.transport.MaxIdleConns)
+	assert.Equal(9, c.transport.MaxConnsPerHost)
+	assert.Equal(1500*time.Millisecond, c.timeout)
+
+	_, err = NewClient(HTTPCliConfig{TLS: TLSConfig{CAFile: "/nonexistent/ca.pem"}})
+	assert.Error(err)
+	_, err = NewClient(HTTPCliConfig{TLS: TLSConfig{CertFile: "/nonexistent/cert.pem"}})
+	assert.Error(err)
+}
+
+func TestClientProxy(t *testing.T) {
+	assert := require.New(t)
+	// A proxy receives requests with the absolute target URL
+	proxied := make(chan string, 10)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+		_, _ = w.Write([]byte(`{"via":"proxy"}`))
+	}))
+	defer proxy.Close()
+
+	c, err := NewClient(HTTPCliConfig{})
+	assert.NoError(err)
+	var out map[string]string
+	assert.NoError(c.Get(context.Background(), "http://target.invalid/x", &out, WithProxies([]string{proxy.URL})))
+	assert.Equal("proxy", out["via"])
+	assert.Equal("http://target.invalid/x", <-proxied)
+
+	// Transport per proxy is created once and shared
+	first := c.httpClient(httpClientOptions{proxies: []string{proxy.URL}}).Transport
+	second := c.httpClient(httpClientOptions{proxies: []string{proxy.URL}}).Transport
+	assert.Same(first, second)
+	assert.NotSame(c.transport, first)
+	assert.Len(c.proxyTransports, 1)
+	// Without proxies, or with an invalid one, the shared transport is used
+	assert.Same(c.transport, c.httpClient(httpClientOptions{}).Transport)
+	assert.Same(c.transport, c.httpClient(httpClientOptions{proxies: []string{"://bad"}}).Transport)
+	c.CloseIdleConnections()
+}
+
+func TestDefaultClient(t *testing.T) {
+	assert := require.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer srv.Close()
+
+	old := Default()
+	defer SetDefault(old)
+	c, err := NewClient(HTTPCliConfig{Address: srv.URL})
+	assert.NoError(err)
+	SetDefault(c)
+	assert.Same(c, Default())
+
+	// Package functions go through the default client
+	out, err := Get[map[string]string](context.Background(), "/hello")
+	assert.NoError(err)
+	assert.Equal("/hello", out["path"])
+}
@@ -2,5 +2,21 @@ package httpclient
 
 // HTTPCliConfig HTTP client configuration
 type HTTPCliConfig struct {
-	Address string `mapstructure:"address"` // Service address
+	Address             string    `mapstructure:"address"`                 // Service address, relative URLs are resolved against it
+	TimeoutMS           int64     `mapstructure:"timeout_ms"`              // Request timeout when ctx has no deadline, defaults to 3000
+	DialTimeoutMS       int64     `mapstructure:"dial_timeout_ms"`         // Connect timeout, defaults to 30000
+	IdleConnTimeoutMS   int64     `mapstructure:"idle_conn_timeout_ms"`    // How long an idle connection is kept, defaults to 90000
+	MaxIdleConns        int       `mapstructure:"max_idle_conns"`          // Idle connections kept over all hosts, defaults to 100
+	MaxIdleConnsPerHost int       `mapstructure:"max_idle_conns_per_host"` // Idle connections kept per host, defaults to 100
+	MaxConnsPerHost     int       `mapstructure:"max_conns_per_host"`      // Connections per host, unlimited if 0
+	TLS                 TLSConfig `mapstructure:"tls"`                     // TLS settings
+}
+
+// TLSConfig TLS settings of an HTTP client
+type TLSConfig struct {
+	CAFile             string `mapstructure:"ca_file"`              // PEM file of CAs trusted besides the system ones
+	CertFile           string `mapstructure:"cert_file"`            // PEM client certificate for mutual TLS
+	KeyFile            string `mapstructure:"key_file"`             // PEM key of the client certificate
+	ServerName         string `mapstructure:"server_name"`          // Server name to verify instead of the URL host
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"` // Skip server certificate verification, for testing only
 }
@@ -0,0 +1,148 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+// BodyDecoder Decodes a response body into out. Responses into *io.Reader, *io.ReadCloser and *struct{}
+// are handled without a decoder
+type BodyDecoder interface {
+	Decode(data []byte, out interface{}) error
+}
+
+type jsonDecoder struct{}
+
+func (jsonDecoder) Decode(data []byte, out interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(out)
+}
+
+// JSONDecoder JSON decoder, numbers decoded into an interface are json.Number. The default
+func JSONDecoder() BodyDecoder {
+	return jsonDecoder{}
+}
+
+var jsoniterNumber = jsoniter.Config{
+	EscapeHTML:             true,
+	SortMapKeys:            true,
+	ValidateJsonRawMessage: true,
+	UseNumber:              true,
+}.Froze()
+
+type jsoniterDecoder struct{}
+
+func (jsoniterDecoder) Decode(data []byte, out interface{}) error {
+	return jsoniterNumber.Unmarshal(data, out)
+}
+
+// JSONIterDecoder JSON decoder using jsoniter, numbers decoded into an interface are json.Number
+func JSONIterDecoder() BodyDecoder {
+	return jsoniterDecoder{}
+}
+
+type xmlDecoder struct{}
+
+func (xmlDecoder) Decode(data []byte, out interface{}) error {
+	return xml.Unmarshal(data, out)
+}
+
+// XMLDecoder XML decoder, the default for XML content types
+func XMLDecoder() BodyDecoder {
+	return xmlDecoder{}
+}
+
+type rawDecoder struct{}
+
+func (rawDecoder) Decode(data []byte, out interface{}) error {
+	switch v := out.(type) {
+	case *[]byte:
+		*v = data
+	case *string:
+		*v = string(data)
+	default:
+		return errors.Errorf("RawDecoder unsupported output %T", out)
+	}
+	return nil
+}
+
+// RawDecoder Body as is into *[]byte or *string, without it these are decoded like any other type
+func RawDecoder() BodyDecoder {
+	return rawDecoder{}
+}
+
+type decoderOption struct {
+	decoder BodyDecoder
+}
+
+func (d decoderOption) apply(o *httpClientOptions) {
+	o.decoder = d.decoder
+}
+
+// WithDecoder Decode responses with decoder instead of choosing one by the response Content-Type
+func WithDecoder(decoder BodyDecoder) Option {
+	return decoderOption{decoder: decoder}
+}
+
+// decoderOf Decoder for the response Content-Type, JSON unless it is XML
+func decoderOf(contentType string) BodyDecoder {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml") {
+		return xmlDecoder{}
+	}
+	return jsonDecoder{}
+}
+
+// decodeBody Decode data into out, with decoder or the one for contentType if nil
+func decodeBody(data []byte, contentType string, out interface{}, decoder BodyDecoder) error {
+	if decoder == nil {
+		decoder = decoderOf(contentType)
+	}
+	return decoder.Decode(data, out)
+}
+
+// isIgnored Whether the response body is not wanted
+func isIgnored(out interface{}) bool {
+	_, ok := out.(*struct{})
+	return out == nil || ok
+}
+
+// isStream Whether out takes the response body as a stream
+func isStream(out interface{}) bool {
+	switch out.(type) {
+	case *io.Reader, *io.ReadCloser:
+		return true
+	}
+	return false
+}
+
+// streamBody Response body handed to the caller, closing it releases the request context
+type streamBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *streamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// setStream Hand body to out, a stream target
+func setStream(out interface{}, body io.ReadCloser) {
+	switch v := out.(type) {
+	case *io.Reader:
+		*v = body
+	case *io.ReadCloser:
+		*v = body
+	}
+}
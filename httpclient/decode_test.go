@@ -0,0 +1,103 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type decodeXML struct {
+	Name string `xml:"name"`
+}
+
+func TestDecode(t *testing.T) {
+	assert := require.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xml":
+			w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+			_, _ = w.Write([]byte(`<r><name>x</name></r>`))
+		case "/string":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`"hello"`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"n":12345678901234567890}`))
+		}
+	}))
+	defer srv.Close()
+	c, err := NewClient(HTTPCliConfig{Address: srv.URL})
+	assert.NoError(err)
+	ctx := context.Background()
+
+	// Numbers are json.Number whichever method and JSON decoder is used
+	var m map[string]interface{}
+	assert.NoError(c.Post(ctx, "/", nil, nil, &m))
+	assert.Equal(json.Number("12345678901234567890"), m["n"])
+	m = nil
+	assert.NoError(c.Get(ctx, "/", &m, WithDecoder(JSONIterDecoder())))
+	assert.Equal(json.Number("12345678901234567890"), m["n"])
+
+	// Decoder chosen by Content-Type
+	var x decodeXML
+	assert.NoError(c.Get(ctx, "/xml", &x))
+	assert.Equal("x", x.Name)
+	assert.Error(c.Get(ctx, "/xml", &x, WithDecoder(JSONDecoder())))
+
+	// Strings and bytes are decoded unless the raw body is asked for
+	var s string
+	assert.NoError(c.Get(ctx, "/string", &s))
+	assert.Equal("hello", s)
+	assert.NoError(c.Get(ctx, "/string", &s, WithDecoder(RawDecoder())))
+	assert.Equal(`"hello"`, s)
+	var b []byte
+	assert.NoError(c.Get(ctx, "/xml", &b, WithDecoder(RawDecoder())))
+	assert.Equal(`<r><name>x</name></r>`, string(b))
+	assert.Error(c.Get(ctx, "/", &m, WithDecoder(RawDecoder())))
+
+	// Ignored body
+	var ignored struct{}
+	assert.NoError(c.Get(ctx, "/xml", &ignored))
+	assert.NoError(c.Get(ctx, "/xml", nil))
+}
+
+func TestDecodeStream(t *testing.T) {
+	assert := require.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-headers" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for i := 0; i < 3; i++ {
+			time.Sleep(40 * time.Millisecond)
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer srv.Close()
+	c, err := NewClient(HTTPCliConfig{Address: srv.URL, TimeoutMS: 50})
+	assert.NoError(err)
+
+	// Body outlasting the client timeout is streamed to the end
+	var rc io.ReadCloser
+	assert.NoError(c.Get(context.Background(), "/", &rc))
+	data, err := io.ReadAll(rc)
+	assert.NoError(err)
+	assert.NoError(rc.Close())
+	assert.Equal("chunkchunkchunk", string(data))
+
+	// The client timeout still covers waiting for the headers
+	var r io.Reader
+	assert.Error(c.Get(context.Background(), "/slow-headers", &r))
+
+	// A decoded body is bound by the client timeout
+	var s string
+	assert.Error(c.Get(context.Background(), "/", &s, WithDecoder(RawDecoder())))
+}
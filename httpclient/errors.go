@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodyLen Bytes of the response body kept in an HTTPError
+const maxErrorBodyLen = 4096
+
+// HTTPError Response with an unexpected status code, use errors.As to get it from a returned error
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte // Up to the first 4KB of the response body
+	Truncated  bool   // Whether the response body was longer than Body
+}
+
+func (e *HTTPError) Error() string {
+	name := e.Method
+	if name != "" {
+		name = name[:1] + strings.ToLower(name[1:])
+	}
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("%s http expect, statusCode: %d", name, e.StatusCode)
+	}
+	return fmt.Sprintf("%s http expect, statusCode: %d, body: %s", name, e.StatusCode, e.Body)
+}
+
+// newHTTPError HTTPError of response, reads up to maxErrorBodyLen bytes of its body
+func newHTTPError(response *http.Response) *HTTPError {
+	e := &HTTPError{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+	}
+	if response.Request != nil {
+		e.Method = response.Request.Method
+		e.URL = response.Request.URL.String()
+	}
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodyLen+1))
+	if len(body) > maxErrorBodyLen {
+		body, e.Truncated = body[:maxErrorBodyLen], true
+	}
+	e.Body = body
+	return e
+}
@@ -0,0 +1,66 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPError(t *testing.T) {
+	assert := require.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":7}`))
+		case "/no-content":
+			w.WriteHeader(http.StatusNoContent)
+		case "/big":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(strings.Repeat("x", maxErrorBodyLen+100)))
+		case "/empty":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Set("X-Request-Id", "42")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"msg":"bad"}`))
+		}
+	}))
+	defer srv.Close()
+	c, err := NewClient(HTTPCliConfig{Address: srv.URL})
+	assert.NoError(err)
+	ctx := context.Background()
+	var out map[string]int
+
+	err = c.Post(ctx, "/invalid?q=1", nil, nil, &out)
+	var httpErr *HTTPError
+	assert.True(errors.As(err, &httpErr))
+	assert.Equal(http.MethodPost, httpErr.Method)
+	assert.Equal(srv.URL+"/invalid?q=1", httpErr.URL)
+	assert.Equal(http.StatusUnprocessableEntity, httpErr.StatusCode)
+	assert.Equal("42", httpErr.Header.Get("X-Request-Id"))
+	assert.Equal(`{"msg":"bad"}`, string(httpErr.Body))
+	assert.False(httpErr.Truncated)
+	assert.Equal(`Post http expect, statusCode: 422, body: {"msg":"bad"}`, err.Error())
+
+	err = c.Get(ctx, "/big", &out)
+	assert.True(errors.As(err, &httpErr))
+	assert.Len(httpErr.Body, maxErrorBodyLen)
+	assert.True(httpErr.Truncated)
+
+	err = c.Get(ctx, "/empty", &out)
+	assert.True(errors.As(err, &httpErr))
+	assert.Equal("Get http expect, statusCode: 404", err.Error())
+
+	// Only 200 is a success unless any 2xx is accepted
+	assert.True(errors.As(c.Post(ctx, "/created", nil, nil, &out), &httpErr))
+	assert.Equal(http.StatusCreated, httpErr.StatusCode)
+	assert.NoError(c.Post(ctx, "/created", nil, nil, &out, WithAny2xx()))
+	assert.Equal(7, out["id"])
+	assert.NoError(c.Put(ctx, "/no-content", nil, nil, &out, WithAny2xx()))
+}
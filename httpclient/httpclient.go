@@ -1,151 +1,51 @@
 package httpclient
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
-	"time"
-
-	"github.com/pkg/errors"
 )
 
+// Get Send a GET request through the default client and decode the response
 func Get[T interface{}](ctx context.Context, urlStr string, opts ...Option) (responseInfo T, err error) {
-	var response *http.Response
-	tp := time.Now()
-	defer func() {
-		handleHttpRespErr(urlStr, tp, response, err)
-	}()
-
-	ctx, cancel := handleCtxDeadline(ctx)
-	defer cancel()
-
-	cli := http.Client{}
-	handleHttpOpt(&cli, opts...)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
-	if err != nil {
-		err = errors.Wrap(err, "Get new req")
-		return
-	}
-	response, err = cli.Do(req)
-	if err != nil {
-		err = errors.Wrap(err, "Get send http")
-		return
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode != http.StatusOK {
-		err = errors.Errorf("Get http expect, statusCode: %d", response.StatusCode)
-		return
-	}
-
-	responseData, err := io.ReadAll(response.Body)
-	if err != nil {
-		err = errors.Wrap(err, "Get read response")
-		return
-	}
-
-	d := json.NewDecoder(bytes.NewReader(responseData))
-	d.UseNumber()
-	err = d.Decode(&responseInfo)
-	if err != nil {
-		err = errors.Wrap(err, fmt.Sprintf("Get unmarshal response %s", string(responseData)))
-		return
-	}
-
+	err = Default().Get(ctx, urlStr, &responseInfo, opts...)
 	return
 }
 
+// Post Send a POST request through the default client and decode the response
 func Post[T interface{}](ctx context.Context, urlStr string, header http.Header, body []byte, opts ...Option) (responseInfo T,
 	err error) {
-	var response *http.Response
-	tp := time.Now()
-	defer func() {
-		handleHttpRespErr(urlStr, tp, response, err)
-	}()
-
-	ctx, cancel := handleCtxDeadline(ctx)
-	defer cancel()
-
-	cli := http.Client{}
-	handleHttpOpt(&cli, opts...)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, urlStr, bytes.NewBuffer(body))
-	if err != nil {
-		err = errors.Wrap(err, "Post new req")
-		return
-	}
-	req.Header = header
-
-	response, err = cli.Do(req)
-	if err != nil {
-		err = errors.Wrap(err, "Post send http")
-		return
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode != http.StatusOK {
-		err = errors.Errorf("Post http expect, statusCode: %d", response.StatusCode)
-		return
-	}
-
-	responseData, err := io.ReadAll(response.Body)
-	if err != nil {
-		err = errors.Wrap(err, "Post read response")
-		return
-	}
-
-	err = json.Unmarshal(responseData, &responseInfo)
-	if err != nil {
-		err = errors.Wrap(err, "Post unmarshal response")
-		return
-	}
+	err = Default().Post(ctx, urlStr, header, body, &responseInfo, opts...)
 	return
 }
 
+// Put Send a PUT request through the default client and decode the response
 func Put[T interface{}](ctx context.Context, urlStr string, header http.Header, body []byte, opts ...Option) (responseInfo T,
 	err error) {
-	var response *http.Response
-	tp := time.Now()
-	defer func() {
-		handleHttpRespErr(urlStr, tp, response, err)
-	}()
-
-	ctx, cancel := handleCtxDeadline(ctx)
-	defer cancel()
-
-	cli := http.Client{}
-	handleHttpOpt(&cli, opts...)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, urlStr, bytes.NewBuffer(body))
-	if err != nil {
-		err = errors.Wrap(err, "Put new req")
-		return
-	}
-	req.Header = header
+	err = Default().Put(ctx, urlStr, header, body, &responseInfo, opts...)
+	return
+}
 
-	response, err = cli.Do(req)
-	if err != nil {
-		err = errors.Wrap(err, "Put send http")
-		return
-	}
-	defer response.Body.Close()
+// Patch Send a PATCH request through the default client and decode the response
+func Patch[T interface{}](ctx context.Context, urlStr string, header http.Header, body []byte, opts ...Option) (responseInfo T,
+	err error) {
+	err = Default().Patch(ctx, urlStr, header, body, &responseInfo, opts...)
+	return
+}
 
-	if response.StatusCode != http.StatusOK {
-		err = errors.Errorf("Put http expect, statusCode: %d", response.StatusCode)
-		return
-	}
+// Delete Send a DELETE request through the default client and decode the response
+func Delete[T interface{}](ctx context.Context, urlStr string, header http.Header, opts ...Option) (responseInfo T,
+	err error) {
+	err = Default().Delete(ctx, urlStr, header, &responseInfo, opts...)
+	return
+}
 
-	responseData, err := io.ReadAll(response.Body)
-	if err != nil {
-		err = errors.Wrap(err, "Put read response")
-		return
-	}
+// Head Send a HEAD request through the default client and return the response header
+func Head(ctx context.Context, urlStr string, header http.Header, opts ...Option) (http.Header, error) {
+	return Default().Head(ctx, urlStr, header, opts...)
+}
 
-	err = json.Unmarshal(responseData, &responseInfo)
-	if err != nil {
-		err = errors.Wrap(err, "Put unmarshal response")
-		return
-	}
+// Do Send req through the default client and decode the response
+func Do[T interface{}](ctx context.Context, req Request, opts ...Option) (responseInfo T, err error) {
+	err = Default().Do(ctx, req, &responseInfo, opts...)
 	return
 }
@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	stdlog "log"
+	"net/http"
+	"time"
+
+	"github.com/ChewZ-life/go-pkg/mq/utils/log"
+)
+
+// RoundTripFunc Sends one attempt of a request
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware Wraps the sending of every attempt, e.g. to add auth headers, sign requests, log, record metrics
+// or trace. Middlewares registered first run outermost
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+type middlewareOption []Middleware
+
+func (m middlewareOption) apply(o *httpClientOptions) {
+	o.middlewares = append(o.middlewares, m...)
+}
+
+// WithMiddleware Add middlewares, those given to NewClient run around those given per call
+func WithMiddleware(middlewares ...Middleware) Option {
+	return middlewareOption(middlewares)
+}
+
+// chain next wrapped by middlewares, the first one outermost
+func chain(next RoundTripFunc, middlewares []Middleware) RoundTripFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		next = middlewares[i](next)
+	}
+	return next
+}
+
+// HeaderMiddleware Set header on every request, e.g. an auth token. Every request gets its own copy of the values
+func HeaderMiddleware(header http.Header) Middleware {
+	header = header.Clone()
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			for key, values := range header {
+				req.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+			}
+			return next(req)
+		}
+	}
+}
+
+// LogMiddleware Log failed requests and requests slower than slowThreshold, to the std log if logger is nil
+func LogMiddleware(logger *log.Log, slowThreshold time.Duration) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			tp := time.Now()
+			response, err := next(req)
+			cost := time.Since(tp)
+
+			fields := log.Fields{"method": req.Method, "url": req.URL.String(), "cost": cost.Milliseconds()}
+			msg := ""
+			switch {
+			case err != nil:
+				msg = "httpclient request fail."
+				fields["err"] = err.Error()
+			case response.StatusCode >= http.StatusBadRequest:
+				msg = "httpclient request fail."
+				fields["statusCode"] = response.StatusCode
+			case slowThreshold > 0 && cost > slowThreshold:
+				msg = "httpclient request slow."
+			default:
+				return response, err
+			}
+
+			if logger == nil {
+				stdlog.Println(msg, fields)
+			} else {
+				logger.WarnWithFields(msg, fields)
+			}
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ChewZ-life/go-pkg/mq/utils/log"
+	"github.com/stretchr/testify/require"
+)
+
+// record Middleware appending name to calls before and after next
+func record(calls *[]string, name string) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			*calls = append(*calls, name+">")
+			response, err := next(req)
+			*calls = append(*calls, "<"+name)
+			return response, err
+		}
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	assert := require.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(r.Header.Get("X-Trace")))
+	}))
+	defer srv.Close()
+
+	var calls []string
+	c, err := NewClient(HTTPCliConfig{Address: srv.URL}, WithMiddleware(record(&calls, "a"), record(&calls, "b"),
+		HeaderMiddleware(http.Header{"Authorization": {"Bearer token"}})))
+	assert.NoError(err)
+	var trace string
+	assert.NoError(c.Get(context.Background(), "/", &trace, WithDecoder(RawDecoder()),
+		WithMiddleware(record(&calls, "c"), HeaderMiddleware(http.Header{"X-Trace": {"t1"}}))))
+	// Registered first runs outermost, client middlewares around per call ones
+	assert.Equal([]string{"a>", "b>", "c>", "<c", "<b", "<a"}, calls)
+	assert.Equal("t1", trace)
+
+	// Middlewares run around every attempt
+	calls = nil
+	c, err = NewClient(HTTPCliConfig{Address: srv.URL}, WithMiddleware(record(&calls, "a")),
+		WithRetry(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetryOn: []int{http.StatusUnauthorized}}))
+	assert.NoError(err)
+	assert.Error(c.Get(context.Background(), "/", nil))
+	assert.Equal([]string{"a>", "<a", "a>", "<a"}, calls)
+
+	// A middleware can short cut the request
+	c, err = NewClient(HTTPCliConfig{Address: srv.URL}, WithMiddleware(func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			return nil, context.DeadlineExceeded
+		}
+	}))
+	assert.NoError(err)
+	assert.ErrorIs(c.Get(context.Background(), "/", nil), context.DeadlineExceeded)
+}
+
+func TestHeaderMiddleware(t *testing.T) {
+	assert := require.New(t)
+	header := http.Header{"x-token": {"t1"}}
+	headerMW := HeaderMiddleware(header)
+	// Appends to the header set by the outer middleware
+	addMW := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Add("X-Token", req.URL.Query().Get("id"))
+			return next(req)
+		}
+	}
+	send := headerMW(addMW(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: req.Header}, nil
+	}))
+
+	var wg sync.WaitGroup
+	values := make([][]string, 20)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost/?id="+strconv.Itoa(i), nil)
+			if response, err := send(req); err == nil {
+				values[i] = response.Header.Values("X-Token")
+			}
+		}(i)
+	}
+	wg.Wait()
+	// Key is canonicalised, values are not shared between requests
+	for i := range values {
+		assert.Equal([]string{"t1", strconv.Itoa(i)}, values[i])
+	}
+
+	// Changing header afterwards does not affect the middleware
+	header["x-token"][0] = "t2"
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/?id=x", nil)
+	response, err := send(req)
+	assert.NoError(err)
+	assert.Equal([]string{"t1", "x"}, response.Header.Values("X-Token"))
+}
+
+func TestLogMiddleware(t *testing.T) {
+	assert := require.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(30 * time.Millisecond)
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	buf := bytes.Buffer{}
+	defer stdlog.SetOutput(stdlog.Writer())
+	stdlog.SetOutput(&buf)
+	c, err := NewClient(HTTPCliConfig{Address: srv.URL}, WithMiddleware(LogMiddleware(nil, 20*time.Millisecond)))
+	assert.NoError(err)
+	ctx := context.Background()
+
+	assert.NoError(c.Get(ctx, "/fast", nil))
+	assert.Empty(buf.String())
+	assert.NoError(c.Get(ctx, "/slow", nil))
+	assert.Contains(buf.String(), "httpclient request slow.")
+	assert.Contains(buf.String(), "/slow")
+	buf.Reset()
+	assert.Error(c.Get(ctx, "/fail", nil))
+	assert.Contains(buf.String(), "httpclient request fail.")
+	assert.Contains(buf.String(), "statusCode:500")
+	buf.Reset()
+	assert.Error(c.Get(ctx, "http://127.0.0.1:1/", nil))
+	assert.Contains(buf.String(), "err:")
+
+	// Logged through the given logger instead of the std log
+	buf.Reset()
+	logger, err := log.NewLog("httpclient", "test", "", 0)
+	assert.NoError(err)
+	c, err = NewClient(HTTPCliConfig{Address: srv.URL}, WithMiddleware(LogMiddleware(logger, 20*time.Millisecond)))
+	assert.NoError(err)
+	assert.Error(c.Get(ctx, "/fail", nil))
+	assert.Empty(buf.String())
+}
@@ -1,11 +1,27 @@
 package httpclient
 
+import "net/http"
+
 type Option interface {
 	apply(o *httpClientOptions)
 }
 
 type httpClientOptions struct {
-	proxies []string // http://host:port
+	proxies []string        // http://host:port
+	retry   *RetryPolicy    // nil if requests are not retried
+	breaker *CircuitBreaker // nil if requests are not guarded by a circuit breaker
+	any2xx  bool            // Any 2xx status is a success, not only 200
+	decoder BodyDecoder     // nil to choose by the response Content-Type
+
+	middlewares []Middleware
+}
+
+// success Whether a response with statusCode is decoded rather than returned as HTTPError
+func (o *httpClientOptions) success(statusCode int) bool {
+	if o.any2xx {
+		return statusCode >= 200 && statusCode < 300
+	}
+	return statusCode == http.StatusOK
 }
 
 type proxiesOption []string
@@ -17,3 +33,14 @@ func (p proxiesOption) apply(o *httpClientOptions) {
 func WithProxies(addrs []string) Option {
 	return proxiesOption(addrs)
 }
+
+type any2xxOption bool
+
+func (a any2xxOption) apply(o *httpClientOptions) {
+	o.any2xx = bool(a)
+}
+
+// WithAny2xx Treat any 2xx status as success instead of only 200, the body of a 204 response is not decoded
+func WithAny2xx() Option {
+	return any2xxOption(true)
+}
@@ -0,0 +1,108 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// Request HTTP request sent by Do
+type Request struct {
+	Method string      // HTTP method, defaults to GET
+	URL    string      // Absolute URL, or relative to the client address
+	Query  url.Values  // Query parameters added to the URL
+	Header http.Header // Request headers
+	Body   BodyEncoder // Request body, nil for none
+}
+
+// BodyEncoder Encodes a request body, the content type is set unless the request header has one
+type BodyEncoder interface {
+	Encode() (data []byte, contentType string, err error)
+}
+
+type jsonBody struct {
+	v interface{}
+}
+
+func (b jsonBody) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(b.v)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "JSONBody marshal")
+	}
+	return data, "application/json", nil
+}
+
+// JSONBody Body of v marshalled to JSON
+func JSONBody(v interface{}) BodyEncoder {
+	return jsonBody{v: v}
+}
+
+type formBody url.Values
+
+func (b formBody) Encode() ([]byte, string, error) {
+	return []byte(url.Values(b).Encode()), "application/x-www-form-urlencoded", nil
+}
+
+// FormBody URL encoded form body
+func FormBody(values url.Values) BodyEncoder {
+	return formBody(values)
+}
+
+// MultipartFile File part of a multipart body
+type MultipartFile struct {
+	FieldName string
+	FileName  string
+	Content   io.Reader
+}
+
+type multipartBody struct {
+	fields map[string]string
+	files  []MultipartFile
+}
+
+func (b multipartBody) Encode() ([]byte, string, error) {
+	buf := bytes.Buffer{}
+	w := multipart.NewWriter(&buf)
+	for name, value := range b.fields {
+		if err := w.WriteField(name, value); err != nil {
+			return nil, "", errors.Wrap(err, "MultipartBody write field")
+		}
+	}
+	for _, file := range b.files {
+		part, err := w.CreateFormFile(file.FieldName, file.FileName)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "MultipartBody create file")
+		}
+		if _, err := io.Copy(part, file.Content); err != nil {
+			return nil, "", errors.Wrap(err, "MultipartBody write file")
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", errors.Wrap(err, "MultipartBody close")
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// MultipartBody multipart/form-data body of form fields and files, file contents are read when the request is sent
+func MultipartBody(fields map[string]string, files ...MultipartFile) BodyEncoder {
+	return multipartBody{fields: fields, files: files}
+}
+
+type rawBody struct {
+	data        []byte
+	contentType string
+}
+
+func (b rawBody) Encode() ([]byte, string, error) {
+	return b.data, b.contentType, nil
+}
+
+// RawBody Body sent as is, contentType may be empty
+func RawBody(data []byte, contentType string) BodyEncoder {
+	return rawBody{data: data, contentType: contentType}
+}
@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// echo What the server received
+type echo struct {
+	Method      string `json:"method"`
+	Query       string `json:"query"`
+	ContentType string `json:"contentType"`
+	Body        string `json:"body"`
+	Field       string `json:"field"`
+	File        string `json:"file"`
+	FileName    string `json:"fileName"`
+}
+
+func newEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := echo{Method: r.Method, Query: r.URL.RawQuery, ContentType: r.Header.Get("Content-Type")}
+		if strings.HasPrefix(e.ContentType, "multipart/form-data") {
+			_ = r.ParseMultipartForm(1 << 20)
+			e.Field = r.FormValue("name")
+			if f, header, err := r.FormFile("upload"); err == nil {
+				data, _ := io.ReadAll(f)
+				e.File, e.FileName = string(data), header.Filename
+			}
+		} else {
+			data, _ := io.ReadAll(r.Body)
+			e.Body = string(data)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(e)
+	}))
+}
+
+func TestRequestBodies(t *testing.T) {
+	assert := require.New(t)
+	srv := newEchoServer()
+	defer srv.Close()
+	c, err := NewClient(HTTPCliConfig{Address: srv.URL})
+	assert.NoError(err)
+	ctx := context.Background()
+
+	var e echo
+	assert.NoError(c.Do(ctx, Request{Method: http.MethodPost, URL: "/", Body: JSONBody(map[string]int{"a": 1})}, &e))
+	assert.Equal("application/json", e.ContentType)
+	assert.JSONEq(`{"a":1}`, e.Body)
+
+	assert.NoError(c.Do(ctx, Request{Method: http.MethodPut, URL: "/", Body: FormBody(url.Values{"a": {"1", "2"}})}, &e))
+	assert.Equal(http.MethodPut, e.Method)
+	assert.Equal("application/x-www-form-urlencoded", e.ContentType)
+	assert.Equal("a=1&a=2", e.Body)
+
+	assert.NoError(c.Do(ctx, Request{Method: http.MethodPost, URL: "/", Body: MultipartBody(map[string]string{"name": "n"},
+		MultipartFile{FieldName: "upload", FileName: "f.txt", Content: strings.NewReader("content")})}, &e))
+	assert.True(strings.HasPrefix(e.ContentType, "multipart/form-data; boundary="))
+	assert.Equal("n", e.Field)
+	assert.Equal("content", e.File)
+	assert.Equal("f.txt", e.FileName)
+
+	// A Content-Type header wins over the encoder one, a raw body without content type sets none
+	assert.NoError(c.Do(ctx, Request{Method: http.MethodPost, URL: "/", Header: http.Header{"Content-Type": {"text/csv"}},
+		Body: RawBody([]byte("a,b"), "text/plain")}, &e))
+	assert.Equal("text/csv", e.ContentType)
+	assert.Equal("a,b", e.Body)
+	assert.NoError(c.Post(ctx, "/", nil, []byte("raw"), &e))
+	assert.Equal("", e.ContentType)
+	assert.Equal("raw", e.Body)
+
+	// Unencodable body fails before sending
+	assert.Error(c.Do(ctx, Request{Method: http.MethodPost, URL: "/", Body: JSONBody(make(chan int))}, &e))
+}
+
+func TestRequestMethodsAndQuery(t *testing.T) {
+	assert := require.New(t)
+	srv := newEchoServer()
+	defer srv.Close()
+	c, err := NewClient(HTTPCliConfig{Address: srv.URL})
+	assert.NoError(err)
+	ctx := context.Background()
+
+	var e echo
+	assert.NoError(c.Do(ctx, Request{URL: "/", Query: url.Values{"b": {"2"}, "a": {"1"}}}, &e))
+	assert.Equal(http.MethodGet, e.Method)
+	assert.Equal("a=1&b=2", e.Query)
+	// Query is appended to one already in the URL
+	assert.NoError(c.Do(ctx, Request{URL: "/?x=0", Query: url.Values{"a": {"1"}}}, &e))
+	assert.Equal("x=0&a=1", e.Query)
+
+	assert.NoError(c.Patch(ctx, "/", nil, []byte("p"), &e))
+	assert.Equal(http.MethodPatch, e.Method)
+	assert.NoError(c.Delete(ctx, "/", nil, &e))
+	assert.Equal(http.MethodDelete, e.Method)
+	header, err := c.Head(ctx, "/", nil)
+	assert.NoError(err)
+	assert.Equal("application/json", header.Get("Content-Type"))
+}
@@ -0,0 +1,140 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy When and how often a failed request is sent again
+type RetryPolicy struct {
+	MaxAttempts    int           // Attempts in total including the first one
+	InitialBackoff time.Duration // Delay before the first retry, doubled every retry, defaults to 100ms
+	MaxBackoff     time.Duration // Upper bound of the delay, defaults to 5s
+	Jitter         float64       // Each delay is reduced by a random amount of up to Jitter times itself, in [0, 1]
+	RetryOn        []int         // Response status codes retried, defaults to 429, 502, 503 and 504
+	AllMethods     bool          // Also retry methods that are not idempotent such as POST and PATCH
+}
+
+// RetryError Request still failing after retries
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Cause Support github.com/pkg/errors.Cause
+func (e *RetryError) Cause() error {
+	return e.Err
+}
+
+var defaultRetryOn = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+type retryOption RetryPolicy
+
+func (r retryOption) apply(o *httpClientOptions) {
+	policy := RetryPolicy(r)
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = 100 * time.Millisecond
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 5 * time.Second
+	}
+	if policy.RetryOn == nil {
+		policy.RetryOn = defaultRetryOn
+	}
+	o.retry = &policy
+}
+
+// WithRetry Retry network errors and responses with a RetryOn status code. Only idempotent methods are retried
+// unless AllMethods is set. A Retry-After response header is honoured when longer than the backoff.
+// All attempts share the deadline of the request context
+func WithRetry(policy RetryPolicy) Option {
+	return retryOption(policy)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable Whether the outcome of an attempt is worth another one
+func (p *RetryPolicy) retryable(ctx context.Context, method string, response *http.Response, err error) bool {
+	if ctx.Err() != nil || (!p.AllMethods && !isIdempotent(method)) {
+		return false
+	}
+	if err != nil {
+		// Network error
+		return true
+	}
+	for _, code := range p.RetryOn {
+		if response.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff Delay before the retry following the given attempt, attempts start at 1
+func (p *RetryPolicy) backoff(attempt int, response *http.Response) time.Duration {
+	delay := p.InitialBackoff
+	for n := 1; n < attempt && delay < p.MaxBackoff; n++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+	if response != nil {
+		if retryAfter := parseRetryAfter(response.Header.Get("Retry-After")); retryAfter > delay {
+			delay = retryAfter
+		}
+	}
+	return delay
+}
+
+// parseRetryAfter Retry-After header in seconds or as HTTP date, 0 if absent or invalid
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// sleep Wait for delay, returns false if ctx is done first
+func sleep(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,165 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	assert := require.New(t)
+	var opt httpClientOptions
+	WithRetry(RetryPolicy{MaxAttempts: 5, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}).apply(&opt)
+	policy := opt.retry
+	assert.Equal(defaultRetryOn, policy.RetryOn)
+	assert.Equal(10*time.Millisecond, policy.backoff(1, nil))
+	assert.Equal(20*time.Millisecond, policy.backoff(2, nil))
+	assert.Equal(40*time.Millisecond, policy.backoff(3, nil))
+	assert.Equal(50*time.Millisecond, policy.backoff(4, nil))
+	assert.Equal(50*time.Millisecond, policy.backoff(100, nil))
+
+	// Retry-After is honoured when longer than the backoff
+	response := &http.Response{Header: http.Header{"Retry-After": {"2"}}}
+	assert.Equal(2*time.Second, policy.backoff(1, response))
+	response.Header.Set("Retry-After", "0")
+	assert.Equal(10*time.Millisecond, policy.backoff(1, response))
+
+	policy.Jitter = 0.5
+	for attempt := 1; attempt < 10; attempt++ {
+		delay := policy.backoff(attempt, nil)
+		policy.Jitter = 0
+		full := policy.backoff(attempt, nil)
+		policy.Jitter = 0.5
+		assert.LessOrEqual(delay, full)
+		assert.GreaterOrEqual(delay, full/2)
+	}
+
+	var defaults httpClientOptions
+	WithRetry(RetryPolicy{MaxAttempts: 2}).apply(&defaults)
+	assert.Equal(100*time.Millisecond, defaults.retry.InitialBackoff)
+	assert.Equal(5*time.Second, defaults.retry.MaxBackoff)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert := require.New(t)
+	assert.Equal(time.Duration(0), parseRetryAfter(""))
+	assert.Equal(time.Duration(0), parseRetryAfter("soon"))
+	assert.Equal(3*time.Second, parseRetryAfter("3"))
+	delay := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.Greater(delay, 58*time.Second)
+	assert.LessOrEqual(delay, time.Minute)
+	assert.LessOrEqual(parseRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)), time.Duration(0))
+}
+
+func TestIsIdempotent(t *testing.T) {
+	assert := require.New(t)
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete} {
+		assert.True(isIdempotent(method), method)
+	}
+	assert.False(isIdempotent(http.MethodPost))
+	assert.False(isIdempotent(http.MethodPatch))
+}
+
+// newFlakyServer Server answering status for the first failures requests of each path, then 200
+func newFlakyServer(failures int32, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	return srv, &calls
+}
+
+func TestRetry(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	// Succeeds on the last attempt
+	srv, calls := newFlakyServer(2, http.StatusServiceUnavailable, "")
+	c, err := NewClient(HTTPCliConfig{Address: srv.URL}, WithRetry(policy))
+	assert.NoError(err)
+	var out map[string]bool
+	assert.NoError(c.Get(ctx, "/", &out))
+	assert.True(out["ok"])
+	assert.Equal(int32(3), calls.Load())
+	srv.Close()
+
+	// Attempts run out, the error tells how many were made
+	srv, calls = newFlakyServer(10, http.StatusBadGateway, "")
+	c, err = NewClient(HTTPCliConfig{Address: srv.URL}, WithRetry(policy))
+	assert.NoError(err)
+	err = c.Get(ctx, "/", &out)
+	var retryErr *RetryError
+	assert.True(errors.As(err, &retryErr))
+	assert.Equal(3, retryErr.Attempts)
+	var httpErr *HTTPError
+	assert.True(errors.As(err, &httpErr))
+	assert.Equal(http.StatusBadGateway, httpErr.StatusCode)
+	assert.Equal(int32(3), calls.Load())
+
+	// Non idempotent methods are sent once unless allowed
+	calls.Store(0)
+	err = c.Post(ctx, "/", nil, nil, &out)
+	assert.False(errors.As(err, &retryErr))
+	assert.Equal(int32(1), calls.Load())
+	calls.Store(0)
+	assert.Error(c.Post(ctx, "/", nil, nil, &out, WithRetry(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond,
+		AllMethods: true})))
+	assert.Equal(int32(2), calls.Load())
+
+	// Status codes outside RetryOn are not retried
+	calls.Store(0)
+	assert.Error(c.Get(ctx, "/", &out, WithRetry(RetryPolicy{MaxAttempts: 3, RetryOn: []int{http.StatusTooManyRequests}})))
+	assert.Equal(int32(1), calls.Load())
+	srv.Close()
+
+	// Network errors are retried
+	c, err = NewClient(HTTPCliConfig{}, WithRetry(policy))
+	assert.NoError(err)
+	err = c.Get(ctx, srv.URL, &out)
+	assert.True(errors.As(err, &retryErr))
+	assert.Equal(3, retryErr.Attempts)
+}
+
+func TestRetryDeadline(t *testing.T) {
+	assert := require.New(t)
+	// Retry-After beyond the deadline ends retrying with the last response
+	srv, calls := newFlakyServer(10, http.StatusTooManyRequests, "10")
+	defer srv.Close()
+	c, err := NewClient(HTTPCliConfig{Address: srv.URL}, WithRetry(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond}))
+	assert.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = c.Get(ctx, "/", nil)
+	assert.Less(time.Since(start), 100*time.Millisecond)
+	var httpErr *HTTPError
+	assert.True(errors.As(err, &httpErr))
+	assert.Equal(http.StatusTooManyRequests, httpErr.StatusCode)
+	assert.Equal(int32(1), calls.Load())
+
+	// Cancelled while waiting between attempts
+	srv2, _ := newFlakyServer(10, http.StatusServiceUnavailable, "")
+	defer srv2.Close()
+	c, err = NewClient(HTTPCliConfig{Address: srv2.URL}, WithRetry(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second}))
+	assert.NoError(err)
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	err = c.Get(ctx, "/", nil)
+	assert.ErrorIs(err, context.Canceled)
+}
@@ -2,31 +2,321 @@ package channel
 
 import (
 	"container/list"
+	"errors"
+	"sync"
+	"sync/atomic"
 )
 
-func NoBlock(chIn chan interface{}) (chOut chan interface{}) {
-	chOut = make(chan interface{})
-	go func() {
-		elements := list.New()
-		for {
-			var element interface{}
-			var chOutIn chan interface{}
-			front := elements.Front()
-			if front != nil {
-				element = front.Value
-				chOutIn = chOut
+// ErrOverflow Buffer reached its max length under OverflowError
+var ErrOverflow = errors.New("channel: buffer overflow")
+
+// OverflowPolicy What a bounded buffer does with an element arriving while it is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock Stop reading the input until an element is delivered
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest Drop the oldest buffered element to make room
+	OverflowDropOldest
+	// OverflowDropNewest Drop the arriving element
+	OverflowDropNewest
+	// OverflowError Drop the arriving element and stop the buffer with ErrOverflow
+	OverflowError
+)
+
+type options[T any] struct {
+	maxLen      int
+	policy      OverflowPolicy
+	overflowCB  func(v T)
+	segmentSize int64
+	marshal     func(v T) ([]byte, error)
+	unmarshal   func(data []byte) (T, error)
+}
+
+type Option[T any] func(o *options[T])
+
+// WithMaxLen Maximum number of buffered elements and what to do beyond it, unbounded if not set
+func WithMaxLen[T any](maxLen int, policy OverflowPolicy) Option[T] {
+	return func(o *options[T]) {
+		o.maxLen = maxLen
+		o.policy = policy
+	}
+}
+
+// WithOverflowCB Called with every element dropped on overflow
+func WithOverflowCB[T any](overflowCB func(v T)) Option[T] {
+	return func(o *options[T]) {
+		o.overflowCB = overflowCB
+	}
+}
+
+// WithSegmentSize Size of the segment files of a spilling buffer, defaults to 16MB
+func WithSegmentSize[T any](size int64) Option[T] {
+	return func(o *options[T]) {
+		o.segmentSize = size
+	}
+}
+
+// WithCodec Encoding of the elements spilled to disk, defaults to JSON
+func WithCodec[T any](marshal func(v T) ([]byte, error), unmarshal func(data []byte) (T, error)) Option[T] {
+	return func(o *options[T]) {
+		o.marshal = marshal
+		o.unmarshal = unmarshal
+	}
+}
+
+// Buffer Decouples a sender from a slow receiver by buffering elements between an input and an output channel.
+// The output is closed once the input is closed and all buffered elements are delivered, or on Stop
+type Buffer[T any] struct {
+	options   options[T]
+	in        <-chan T
+	out       chan T
+	elements  *list.List    // Owned by the buffer goroutine until it exits
+	disk      *diskQueue[T] // Elements beyond memLen when spilling, they are newer than all elements in memory
+	memLen    int           // Number of elements kept in memory before spilling
+	len       atomic.Int64  // Number of buffered elements
+	dropped   atomic.Int64  // Number of elements dropped on overflow
+	err       error         // ErrOverflow or disk error the buffer stopped with, readable after doneCh is closed
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	requeueCh chan []T
+}
+
+// NewNoBlock Start buffering chIn, elements are read from Out in the order they were sent
+func NewNoBlock[T any](chIn <-chan T, opts ...Option[T]) *Buffer[T] {
+	b := newBuffer(chIn, opts)
+	go b.run()
+	return b
+}
+
+// NewSpillBuffer Buffer keeping up to memLen elements in memory and spilling the rest to segment files in dir.
+// Elements left in dir by a previous buffer are delivered first, Stop persists the undelivered elements.
+// Elements are replayed at least once: after a crash the elements read from the segment being consumed
+// are delivered again. Segment writes are not fsynced, elements in memory and writes not yet flushed
+// by the OS are lost on a crash
+func NewSpillBuffer[T any](chIn <-chan T, dir string, memLen int, opts ...Option[T]) (*Buffer[T], error) {
+	b := newBuffer(chIn, opts)
+	if b.options.segmentSize <= 0 {
+		b.options.segmentSize = defaultSegmentSize
+	}
+	if b.options.marshal == nil || b.options.unmarshal == nil {
+		b.options.marshal, b.options.unmarshal = jsonMarshal[T], jsonUnmarshal[T]
+	}
+	disk, err := openDiskQueue(dir, b.options.segmentSize, b.options.marshal, b.options.unmarshal)
+	if err != nil {
+		return nil, err
+	}
+	b.disk, b.memLen = disk, memLen
+	b.len.Store(int64(disk.len))
+	go b.run()
+	return b, nil
+}
+
+func newBuffer[T any](chIn <-chan T, opts []Option[T]) *Buffer[T] {
+	b := &Buffer[T]{
+		in:        chIn,
+		out:       make(chan T),
+		elements:  list.New(),
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+		requeueCh: make(chan []T),
+	}
+	for _, opt := range opts {
+		opt(&b.options)
+	}
+	return b
+}
+
+// NoBlock Unbounded buffer over chIn, returns the output channel
+func NoBlock[T any](chIn chan T) (chOut chan T) {
+	return NewNoBlock[T](chIn).out
+}
+
+// value Element stored in a list element. The assertion would panic on a nil element of an interface type
+func value[T any](e *list.Element) T {
+	v, _ := e.Value.(T)
+	return v
+}
+
+func (b *Buffer[T]) run() {
+	defer close(b.doneCh)
+	defer close(b.out)
+	defer b.persist()
+
+	in := b.in
+	for {
+		if !b.load() {
+			return
+		}
+		var element T
+		var out chan T
+		front := b.elements.Front()
+		if front != nil {
+			element = value[T](front)
+			out = b.out
+		} else if in == nil {
+			// Input closed and buffer drained
+			return
+		}
+		recv := in
+		if b.full() && b.options.policy == OverflowBlock {
+			recv = nil
+		}
+
+		select {
+		case element, ok := <-recv:
+			if !ok {
+				in = nil
+				continue
+			}
+			if !b.push(element) {
+				return
+			}
+		case out <- element:
+			b.elements.Remove(front)
+			b.len.Add(-1)
+		case elements := <-b.requeueCh:
+			for k := len(elements) - 1; k >= 0; k-- {
+				b.elements.PushFront(elements[k])
 			}
-			select {
-			case element, ok := <-chIn:
-				if !ok {
-					close(chOut)
-					return
-				}
-				elements.PushBack(element)
-			case chOutIn <- element:
-				elements.Remove(front)
+			b.len.Add(int64(len(elements)))
+		case <-b.stopCh:
+			return
+		}
+	}
+}
+
+func (b *Buffer[T]) full() bool {
+	return b.options.maxLen > 0 && b.Len() >= b.options.maxLen
+}
+
+// load Bring the oldest spilled element into memory once memory runs empty, returns false on disk error
+func (b *Buffer[T]) load() bool {
+	if b.disk == nil || b.elements.Len() > 0 || b.disk.len == 0 {
+		return true
+	}
+	element, err := b.disk.pop()
+	if err != nil {
+		b.err = err
+		return false
+	}
+	b.elements.PushBack(element)
+	return true
+}
+
+// persist Write the elements in memory to disk ahead of the spilled ones and close the segment files,
+// so undelivered elements are replayed after restart
+func (b *Buffer[T]) persist() {
+	if b.disk == nil {
+		return
+	}
+	front := make([]T, 0, b.elements.Len())
+	for e := b.elements.Front(); e != nil; e = e.Next() {
+		front = append(front, value[T](e))
+	}
+	if err := b.disk.close(front); err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return
+	}
+	b.elements.Init()
+}
+
+// push Buffer element applying the overflow policy, returns false if the buffer has to stop
+func (b *Buffer[T]) push(element T) bool {
+	if b.full() {
+		switch b.options.policy {
+		case OverflowDropOldest:
+			if !b.load() {
+				return false
 			}
+			front := b.elements.Front()
+			b.elements.Remove(front)
+			b.drop(value[T](front))
+			b.len.Add(-1)
+		case OverflowDropNewest:
+			b.drop(element)
+			return true
+		case OverflowError:
+			b.drop(element)
+			b.err = ErrOverflow
+			return false
+		}
+	}
+	if b.disk != nil && (b.disk.len > 0 || b.elements.Len() >= b.memLen) {
+		if err := b.disk.push(element); err != nil {
+			b.err = err
+			return false
 		}
-	}()
-	return
-}
\ No newline at end of file
+	} else {
+		b.elements.PushBack(element)
+	}
+	b.len.Add(1)
+	return true
+}
+
+func (b *Buffer[T]) drop(element T) {
+	b.dropped.Add(1)
+	if b.options.overflowCB != nil {
+		b.options.overflowCB(element)
+	}
+}
+
+// Out Channel delivering buffered elements
+func (b *Buffer[T]) Out() <-chan T {
+	return b.out
+}
+
+// Requeue Put elements back at the front of the buffer in the given order, e.g. elements taken from Out
+// that could not be handled. Ignores the max length, returns false if the buffer has stopped
+func (b *Buffer[T]) Requeue(elements ...T) bool {
+	select {
+	case b.requeueCh <- elements:
+		return true
+	case <-b.doneCh:
+		return false
+	}
+}
+
+// Len Number of elements waiting in the buffer
+func (b *Buffer[T]) Len() int {
+	return int(b.len.Load())
+}
+
+// Dropped Number of elements dropped on overflow
+func (b *Buffer[T]) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// Stop Stop reading the input without closing it and close the output, returns the undelivered elements.
+// A spilling buffer persists its undelivered elements to disk instead, returning none unless that fails
+func (b *Buffer[T]) Stop() []T {
+	b.stopOnce.Do(func() {
+		close(b.stopCh)
+	})
+	<-b.doneCh
+
+	elements := make([]T, 0, b.elements.Len())
+	for e := b.elements.Front(); e != nil; e = e.Next() {
+		elements = append(elements, value[T](e))
+	}
+	return elements
+}
+
+// Done Closed once the buffer has stopped and the output is closed
+func (b *Buffer[T]) Done() <-chan struct{} {
+	return b.doneCh
+}
+
+// Err ErrOverflow if the buffer was stopped by OverflowError, the disk error if a spilling buffer failed,
+// nil otherwise. Valid once Done is closed
+func (b *Buffer[T]) Err() error {
+	select {
+	case <-b.doneCh:
+		return b.err
+	default:
+		return nil
+	}
+}
@@ -3,6 +3,7 @@ package channel
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -39,4 +40,88 @@ func TestNoBlock(t *testing.T) {
 	}
 	close(ch1)
 	wg.Wait()
+	// Elements buffered when the input is closed are still delivered
+	assert.Equal(cnt, nextExpect)
+}
+
+func TestNoBlockNil(t *testing.T) {
+	assert := require.New(t)
+	ch1 := make(chan interface{})
+	ch2 := NoBlock(ch1)
+	ch1 <- nil
+	ch1 <- 1
+	close(ch1)
+	assert.Nil(<-ch2)
+	assert.Equal(1, <-ch2)
+	_, ok := <-ch2
+	assert.False(ok)
+
+	// Nil elements dropped on overflow and returned by Stop
+	var dropped []error
+	ch3 := make(chan error)
+	b := NewNoBlock(ch3,
+		WithMaxLen[error](1, OverflowDropOldest),
+		WithOverflowCB(func(v error) { dropped = append(dropped, v) }),
+	)
+	ch3 <- nil
+	ch3 <- nil
+	assert.Eventually(func() bool { return b.Len() == 1 }, time.Second, time.Millisecond)
+	assert.Equal([]error{nil}, b.Stop())
+	assert.Equal([]error{nil}, dropped)
+}
+
+func TestNoBlockOverflow(t *testing.T) {
+	assert := require.New(t)
+
+	// waitLen Wait until the buffer goroutine has taken in the sent elements
+	waitLen := func(b *Buffer[int], n int) {
+		assert.Eventually(func() bool { return b.Len() == n }, time.Second, time.Millisecond)
+	}
+
+	in := make(chan int)
+	var dropped []int
+	b := NewNoBlock[int](in, WithMaxLen[int](3, OverflowDropOldest), WithOverflowCB(func(v int) {
+		dropped = append(dropped, v)
+	}))
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	waitLen(b, 3)
+	assert.Equal([]int{2, 3, 4}, b.Stop())
+	assert.Equal([]int{0, 1}, dropped)
+	assert.Equal(int64(2), b.Dropped())
+	_, ok := <-b.Out()
+	assert.False(ok)
+
+	in = make(chan int)
+	b = NewNoBlock[int](in, WithMaxLen[int](3, OverflowDropNewest))
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	waitLen(b, 3)
+	assert.Equal([]int{0, 1, 2}, b.Stop())
+
+	in = make(chan int)
+	b = NewNoBlock[int](in, WithMaxLen[int](3, OverflowBlock))
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	select {
+	case in <- 3:
+		assert.Fail("full buffer should not read the input")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Equal(0, <-b.Out())
+	in <- 3
+	waitLen(b, 3)
+	assert.Equal([]int{1, 2, 3}, b.Stop())
+	assert.NoError(b.Err())
+
+	in = make(chan int)
+	b = NewNoBlock[int](in, WithMaxLen[int](1, OverflowError))
+	in <- 0
+	in <- 1
+	<-b.Done()
+	assert.ErrorIs(b.Err(), ErrOverflow)
+	assert.Equal([]int{0}, b.Stop())
 }
\ No newline at end of file
@@ -0,0 +1,130 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// buffer NoBlock over in that is stopped when ctx is done, so a reader gone away does not leak the buffer
+func buffer[T any](ctx context.Context, in <-chan T, opts []Option[T]) <-chan T {
+	b := NewNoBlock(in, opts...)
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.Stop()
+		case <-b.Done():
+		}
+	}()
+	return b.Out()
+}
+
+// send Pass v to out unless ctx is done first
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// distribute Read in until it is closed or ctx is done, passing each element to the outputs chosen by route.
+// Outputs are buffered with opts and closed once distribution ends
+func distribute[T any](ctx context.Context, in <-chan T, n int, opts []Option[T],
+	route func(v T, mids []chan T) []chan T) []<-chan T {
+	mids := make([]chan T, n)
+	outs := make([]<-chan T, n)
+	for k := range mids {
+		mids[k] = make(chan T)
+		outs[k] = buffer(ctx, mids[k], opts)
+	}
+
+	go func() {
+		defer func() {
+			for _, mid := range mids {
+				close(mid)
+			}
+		}()
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				for _, mid := range route(v, mids) {
+					if !send(ctx, mid, v) {
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return outs
+}
+
+// Merge Single output carrying the elements of all ins, closed once every input is closed.
+// Elements of one input keep their order
+func Merge[T any](ctx context.Context, ins []<-chan T, opts ...Option[T]) <-chan T {
+	mid := make(chan T)
+	wg := sync.WaitGroup{}
+	for _, in := range ins {
+		wg.Add(1)
+		go func(in <-chan T) {
+			defer wg.Done()
+			for {
+				select {
+				case v, ok := <-in:
+					if !ok || !send(ctx, mid, v) {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(in)
+	}
+	go func() {
+		wg.Wait()
+		close(mid)
+	}()
+	return buffer(ctx, mid, opts)
+}
+
+// FanOut Split in over n outputs round-robin, so every element is handled by one of n processors
+func FanOut[T any](ctx context.Context, in <-chan T, n int, opts ...Option[T]) []<-chan T {
+	next := 0
+	return distribute(ctx, in, n, opts, func(v T, mids []chan T) []chan T {
+		mid := mids[next]
+		next = (next + 1) % len(mids)
+		return []chan T{mid}
+	})
+}
+
+// FanOutByKey Split in over n outputs by the key of each element, elements sharing a key go to
+// the same output in order
+func FanOutByKey[T any, K comparable](ctx context.Context, in <-chan T, n int, key func(v T) K,
+	opts ...Option[T]) []<-chan T {
+	return distribute(ctx, in, n, opts, func(v T, mids []chan T) []chan T {
+		h := fnv.New32a()
+		_, _ = fmt.Fprint(h, key(v))
+		return []chan T{mids[h.Sum32()%uint32(len(mids))]}
+	})
+}
+
+// Broadcast Copy every element of in to n outputs, each output is buffered on its own
+// so a slow reader does not hold up the others
+func Broadcast[T any](ctx context.Context, in <-chan T, n int, opts ...Option[T]) []<-chan T {
+	return distribute(ctx, in, n, opts, func(v T, mids []chan T) []chan T {
+		return mids
+	})
+}
+
+// Tee Copy every element of in to two outputs
+func Tee[T any](ctx context.Context, in <-chan T, opts ...Option[T]) (<-chan T, <-chan T) {
+	outs := Broadcast(ctx, in, 2, opts...)
+	return outs[0], outs[1]
+}
@@ -0,0 +1,131 @@
+package channel
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// collect Read all outputs until they are closed
+func collect[T any](outs ...<-chan T) [][]T {
+	results := make([][]T, len(outs))
+	wg := sync.WaitGroup{}
+	for k, out := range outs {
+		wg.Add(1)
+		go func(k int, out <-chan T) {
+			defer wg.Done()
+			for v := range out {
+				results[k] = append(results[k], v)
+			}
+		}(k, out)
+	}
+	wg.Wait()
+	return results
+}
+
+// produce Channel sending 0..n-1 and then closed
+func produce(n int) <-chan int {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for i := 0; i < n; i++ {
+			ch <- i
+		}
+	}()
+	return ch
+}
+
+func sequence(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+	return s
+}
+
+func TestMerge(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+	merged := collect(Merge(ctx, []<-chan int{produce(100), produce(100), produce(100)}))[0]
+	assert.Len(merged, 300)
+	sort.Ints(merged)
+	for i, v := range merged {
+		assert.Equal(i/3, v)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	results := collect(FanOut(ctx, produce(100), 4)...)
+	for k, result := range results {
+		assert.Len(result, 25)
+		assert.Equal(k, result[0])
+	}
+
+	results = collect(FanOutByKey(ctx, produce(100), 4, func(v int) int { return v % 10 })...)
+	total := 0
+	for _, result := range results {
+		total += len(result)
+		// Every key lives in one output, in order
+		for k := 1; k < len(result); k++ {
+			assert.Less(result[k-1], result[k])
+		}
+	}
+	assert.Equal(100, total)
+	keyOut := map[int]int{}
+	for k, result := range results {
+		for _, v := range result {
+			if out, ok := keyOut[v%10]; ok {
+				assert.Equal(out, k)
+			}
+			keyOut[v%10] = k
+		}
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	for _, result := range collect(Broadcast(ctx, produce(100), 3)...) {
+		assert.Equal(sequence(100), result)
+	}
+	a, b := Tee(ctx, produce(100))
+	// A reader lagging behind does not hold up the other one
+	assert.Equal(sequence(100), collect(a)[0])
+	assert.Equal(sequence(100), collect(b)[0])
+}
+
+func TestFanOutCancel(t *testing.T) {
+	assert := require.New(t)
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	merged := Merge(ctx, []<-chan int{in})
+	outs := Broadcast(ctx, merged, 2)
+	split := FanOut(ctx, outs[0], 2)
+	keyed := FanOutByKey(ctx, outs[1], 2, func(v int) int { return v })
+	in <- 1
+	in <- 2
+	<-split[0]
+
+	// Nobody reads the remaining outputs, cancel still releases every goroutine
+	cancel()
+	for _, out := range append(split, keyed...) {
+		for range out {
+		}
+	}
+	// Not assert.Eventually, which checks from a goroutine of its own
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+		assert.True(time.Now().Before(deadline), "goroutines leaked")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
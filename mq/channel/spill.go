@@ -0,0 +1,306 @@
+package channel
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	segmentExt = ".seg"
+	// firstSegment Sequence of the first segment in an empty directory, leaves room for segments
+	// written ahead of it when a stopped buffer persists its memory
+	firstSegment = uint64(1) << 40
+	// defaultSegmentSize Segment file size after which a new segment is started
+	defaultSegmentSize = 16 << 20
+)
+
+// diskQueue FIFO of encoded elements in append only segment files, records are a 4 byte big endian length
+// followed by the encoded element. Fully read segments are deleted. Not safe for concurrent use
+type diskQueue[T any] struct {
+	dir         string
+	segmentSize int64
+	marshal     func(v T) ([]byte, error)
+	unmarshal   func(data []byte) (T, error)
+
+	segments []uint64 // Sequences of the segment files in reading order
+	len      int      // Number of unread elements over all segments
+	w        *os.File // Last segment, open for appending
+	wSize    int64
+	r        *os.File // First segment, open for reading
+	rReader  *bufio.Reader
+}
+
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// openDiskQueue Open the segments left in dir, their elements are read first
+func openDiskQueue[T any](dir string, segmentSize int64, marshal func(v T) ([]byte, error),
+	unmarshal func(data []byte) (T, error)) (*diskQueue[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "channel openDiskQueue mkdir")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "channel openDiskQueue read dir")
+	}
+
+	q := &diskQueue[T]{
+		dir:         dir,
+		segmentSize: segmentSize,
+		marshal:     marshal,
+		unmarshal:   unmarshal,
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		q.segments = append(q.segments, seq)
+	}
+	sort.Slice(q.segments, func(i, j int) bool {
+		return q.segments[i] < q.segments[j]
+	})
+	for _, seq := range q.segments {
+		n, err := recoverSegment(segmentPath(dir, seq))
+		if err != nil {
+			return nil, err
+		}
+		q.len += n
+	}
+	return q, nil
+}
+
+// recoverSegment Count the records of a segment, a record torn by a crash is cut off
+func recoverSegment(path string) (int, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return 0, errors.Wrap(err, "channel recoverSegment open")
+	}
+	defer f.Close()
+
+	n, size := 0, int64(0)
+	r := bufio.NewReader(f)
+	for {
+		data, err := readRecord(r)
+		if err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return 0, errors.Wrap(err, "channel recoverSegment read")
+		}
+		n++
+		size += int64(4 + len(data))
+	}
+	if err := f.Truncate(size); err != nil {
+		return 0, errors.Wrap(err, "channel recoverSegment truncate")
+	}
+	return n, nil
+}
+
+func readRecord(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (q *diskQueue[T]) writeRecord(w io.Writer, v T) (int64, error) {
+	data, err := q.marshal(v)
+	if err != nil {
+		return 0, errors.Wrap(err, "channel diskQueue marshal")
+	}
+	record := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(record, uint32(len(data)))
+	copy(record[4:], data)
+	if _, err := w.Write(record); err != nil {
+		return 0, errors.Wrap(err, "channel diskQueue write")
+	}
+	return int64(len(record)), nil
+}
+
+// push Append v to the last segment, a new segment is started once the last one is full
+func (q *diskQueue[T]) push(v T) error {
+	if q.w == nil || q.wSize >= q.segmentSize {
+		if err := q.closeWriter(); err != nil {
+			return err
+		}
+		seq := firstSegment
+		if len(q.segments) > 0 {
+			seq = q.segments[len(q.segments)-1]
+			if q.wSize >= q.segmentSize || q.r != nil {
+				// Last segment is full or being read, appending to it would confuse the reader
+				seq++
+			}
+		}
+		f, err := os.OpenFile(segmentPath(q.dir, seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return errors.Wrap(err, "channel diskQueue open segment")
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return errors.Wrap(err, "channel diskQueue stat segment")
+		}
+		if len(q.segments) == 0 || q.segments[len(q.segments)-1] != seq {
+			q.segments = append(q.segments, seq)
+		}
+		q.w, q.wSize = f, info.Size()
+	}
+
+	n, err := q.writeRecord(q.w, v)
+	if err != nil {
+		return err
+	}
+	q.wSize += n
+	q.len++
+	return nil
+}
+
+// pop Read the oldest element, q.len must be positive
+func (q *diskQueue[T]) pop() (v T, err error) {
+	for {
+		if q.r == nil {
+			f, err := os.Open(segmentPath(q.dir, q.segments[0]))
+			if err != nil {
+				return v, errors.Wrap(err, "channel diskQueue open segment")
+			}
+			q.r, q.rReader = f, bufio.NewReader(f)
+		}
+
+		data, err := readRecord(q.rReader)
+		if err == io.EOF && len(q.segments) > 1 {
+			// Segment consumed, move on to the next one
+			if err := q.removeFirst(); err != nil {
+				return v, err
+			}
+			continue
+		}
+		if err != nil {
+			return v, errors.Wrap(err, "channel diskQueue read")
+		}
+		if v, err = q.unmarshal(data); err != nil {
+			return v, errors.Wrap(err, "channel diskQueue unmarshal")
+		}
+		q.len--
+		if q.len == 0 {
+			// Everything read, start over with a fresh segment
+			err = q.removeFirst()
+		}
+		return v, err
+	}
+}
+
+// removeFirst Delete the segment being read
+func (q *diskQueue[T]) removeFirst() error {
+	q.r.Close()
+	q.r, q.rReader = nil, nil
+	if len(q.segments) == 1 {
+		if err := q.closeWriter(); err != nil {
+			return err
+		}
+		q.wSize = 0
+	}
+	if err := os.Remove(segmentPath(q.dir, q.segments[0])); err != nil {
+		return errors.Wrap(err, "channel diskQueue remove segment")
+	}
+	q.segments = q.segments[1:]
+	return nil
+}
+
+func (q *diskQueue[T]) closeWriter() error {
+	if q.w == nil {
+		return nil
+	}
+	err := q.w.Close()
+	q.w = nil
+	return errors.Wrap(err, "channel diskQueue close segment")
+}
+
+// close Persist front ahead of the unread elements and close the segment files. The partly read segment
+// is rewritten without its read records, so they are not replayed on the next open
+func (q *diskQueue[T]) close(front []T) error {
+	if err := q.closeWriter(); err != nil {
+		return err
+	}
+	if q.r == nil && len(front) == 0 {
+		return nil
+	}
+
+	seq := firstSegment
+	if len(q.segments) > 0 {
+		seq = q.segments[0] - 1
+	}
+	records := front
+	if q.r != nil {
+		seq = q.segments[0]
+		for {
+			data, err := readRecord(q.rReader)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return errors.Wrap(err, "channel diskQueue read")
+			}
+			v, err := q.unmarshal(data)
+			if err != nil {
+				return errors.Wrap(err, "channel diskQueue unmarshal")
+			}
+			records = append(records, v)
+		}
+		q.r.Close()
+		q.r, q.rReader = nil, nil
+	}
+
+	tmp := segmentPath(q.dir, seq) + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return errors.Wrap(err, "channel diskQueue create segment")
+	}
+	w := bufio.NewWriter(f)
+	for _, v := range records {
+		if _, err := q.writeRecord(w, v); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return errors.Wrap(err, "channel diskQueue flush")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "channel diskQueue close segment")
+	}
+	return errors.Wrap(os.Rename(tmp, segmentPath(q.dir, seq)), "channel diskQueue rename segment")
+}
+
+func jsonMarshal[T any](v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func jsonUnmarshal[T any](data []byte) (v T, err error) {
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
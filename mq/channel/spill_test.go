@@ -0,0 +1,120 @@
+package channel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type spillMsg struct {
+	Key   string `json:"key"`
+	Value int    `json:"value"`
+}
+
+func TestSpillBuffer(t *testing.T) {
+	assert := require.New(t)
+	dir := t.TempDir()
+	segments := func() []string {
+		files, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+		return files
+	}
+
+	in := make(chan spillMsg)
+	b, err := NewSpillBuffer[spillMsg](in, dir, 4, WithSegmentSize[spillMsg](128))
+	assert.NoError(err)
+	for i := 0; i < 50; i++ {
+		in <- spillMsg{Key: "k", Value: i}
+	}
+	assert.Eventually(func() bool { return b.Len() == 50 }, time.Second, time.Millisecond)
+	assert.Greater(len(segments()), 1)
+	for i := 0; i < 10; i++ {
+		assert.Equal(i, (<-b.Out()).Value)
+	}
+	// Undelivered elements go to disk
+	assert.Empty(b.Stop())
+	assert.NoError(b.Err())
+
+	// Restarted buffer replays them in order before new elements
+	in = make(chan spillMsg)
+	b, err = NewSpillBuffer[spillMsg](in, dir, 4, WithSegmentSize[spillMsg](128))
+	assert.NoError(err)
+	assert.Equal(40, b.Len())
+	go func() {
+		for i := 50; i < 60; i++ {
+			in <- spillMsg{Key: "k", Value: i}
+		}
+		close(in)
+	}()
+	next := 10
+	for msg := range b.Out() {
+		assert.Equal(next, msg.Value)
+		next++
+	}
+	assert.Equal(60, next)
+	assert.NoError(b.Err())
+	assert.Empty(segments())
+}
+
+func TestSpillBufferTornRecord(t *testing.T) {
+	assert := require.New(t)
+	dir := t.TempDir()
+
+	in := make(chan int)
+	b, err := NewSpillBuffer[int](in, dir, 0)
+	assert.NoError(err)
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	assert.Eventually(func() bool { return b.Len() == 3 }, time.Second, time.Millisecond)
+	assert.Empty(b.Stop())
+
+	// Crash in the middle of writing a record
+	f, err := os.OpenFile(segmentPath(dir, firstSegment), os.O_WRONLY|os.O_APPEND, 0)
+	assert.NoError(err)
+	_, err = f.Write([]byte{0, 0, 0, 9, '1'})
+	assert.NoError(err)
+	assert.NoError(f.Close())
+
+	in = make(chan int)
+	b, err = NewSpillBuffer[int](in, dir, 0)
+	assert.NoError(err)
+	assert.Equal(3, b.Len())
+	close(in)
+	var values []int
+	for v := range b.Out() {
+		values = append(values, v)
+	}
+	assert.Equal([]int{0, 1, 2}, values)
+}
+
+func TestSpillBufferRequeue(t *testing.T) {
+	assert := require.New(t)
+	dir := t.TempDir()
+
+	in := make(chan int)
+	b, err := NewSpillBuffer[int](in, dir, 1)
+	assert.NoError(err)
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	assert.Eventually(func() bool { return b.Len() == 3 }, time.Second, time.Millisecond)
+	// Element taken but not handled goes back ahead of the rest and is persisted with them
+	assert.Equal(0, <-b.Out())
+	assert.True(b.Requeue(0))
+	assert.Equal(3, b.Len())
+	assert.Empty(b.Stop())
+	assert.False(b.Requeue(0))
+
+	in = make(chan int)
+	b, err = NewSpillBuffer[int](in, dir, 1)
+	assert.NoError(err)
+	close(in)
+	var values []int
+	for v := range b.Out() {
+		values = append(values, v)
+	}
+	assert.Equal([]int{0, 1, 2}, values)
+}
@@ -4,14 +4,19 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
+	"net/http"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ChewZ-life/go-pkg/mq/channel"
 	"github.com/ChewZ-life/go-pkg/mq/utils/log"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sns"
 	jsoniter "github.com/json-iterator/go"
@@ -20,28 +25,57 @@ import (
 
 const (
 	TimeoutMS = int64(1000)
+
+	retryInitialDelay = 100 * time.Millisecond // Delay before resending a spilled message the first time
+	retryMaxDelay     = 30 * time.Second       // Upper bound of the delay between resends
 )
 
+// ErrProducerClosed Producer has been shut down
+var ErrProducerClosed = errors.New("sns: producer closed")
+
 type keyValueReq struct {
 	key   string
 	value string
 	errCh chan error
 }
 
+// spilledReq Form of keyValueReq spilled to disk
+type spilledReq struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func marshalReq(req keyValueReq) ([]byte, error) {
+	return jsoniter.Marshal(spilledReq{Key: req.key, Value: req.value})
+}
+
+func unmarshalReq(data []byte) (keyValueReq, error) {
+	var req spilledReq
+	err := jsoniter.Unmarshal(data, &req)
+	return keyValueReq{key: req.Key, value: req.Value}, err
+}
+
 // SNSConfig AWS SNS related configuration
 type SNSConfig struct {
-	ARN         string `mapstructure:"arn" json:"arn"`             // Topic ARN
-	Region      string `mapstructure:"region" json:"region"`       // Queue service region
-	APIKey      string `mapstructure:"api_key" json:"api_key"`     // API key
-	SecretKey   string `mapstructure:"secret_key" json:"secret_key"` // Secret key
-	ProducerCnt int    `mapstructure:"producer_cnt" json:"producer_cnt"` // Number of producers
+	ARN              string `mapstructure:"arn" json:"arn"`                               // Topic ARN
+	Region           string `mapstructure:"region" json:"region"`                         // Queue service region
+	APIKey           string `mapstructure:"api_key" json:"api_key"`                       // API key
+	SecretKey        string `mapstructure:"secret_key" json:"secret_key"`                 // Secret key
+	ProducerCnt      int    `mapstructure:"producer_cnt" json:"producer_cnt"`             // Number of producers
+	SpillDir         string `mapstructure:"spill_dir" json:"spill_dir"`                   // Directory pending messages are spilled to, Pub does not wait for the send result and failed sends are retried if set
+	SpillMemLen      int    `mapstructure:"spill_mem_len" json:"spill_mem_len"`           // Pending messages kept in memory per producer before spilling
+	SpillMaxAttempts int    `mapstructure:"spill_max_attempts" json:"spill_max_attempts"` // Sends of a spilled message before it is dropped, unlimited if 0. Messages rejected by SNS are dropped right away
 }
 
-// Producer Message producer
+// Producer Message producer, call Shutdown to persist pending messages when spilling
 type Producer struct {
-	config   SNSConfig                // Configuration
-	logger   *log.Log                 // Logger
-	msgChans map[int]chan interface{} // Message channels
+	config   SNSConfig                      // Configuration
+	logger   *log.Log                       // Logger
+	msgChans map[int]chan keyValueReq       // Message channels
+	buffers  []*channel.Buffer[keyValueReq] // Pending messages per producer, replaced by an in-memory buffer if spilling fails
+	stopOnce sync.Once
+	stopCh   chan struct{}  // Closed on Shutdown
+	wg       sync.WaitGroup // Running processMessages goroutines
 	isFifo   bool
 }
 
@@ -49,21 +83,46 @@ func NewProducer(snsConfig SNSConfig, logger *log.Log) *Producer {
 	p := &Producer{
 		config:   snsConfig,
 		logger:   logger,
-		msgChans: map[int]chan interface{}{},
+		msgChans: map[int]chan keyValueReq{},
+		buffers:  make([]*channel.Buffer[keyValueReq], snsConfig.ProducerCnt),
+		stopCh:   make(chan struct{}),
 		isFifo:   strings.HasSuffix(snsConfig.ARN, ".fifo"),
 	}
 
 	for i := 0; i < snsConfig.ProducerCnt; i++ {
-		p.msgChans[i] = make(chan interface{})
-		keyValueCh := channel.NoBlock(p.msgChans[i])
-		go func(i int, keyValueCh chan interface{}) {
-			go p.processMessages(i, keyValueCh)
-		}(i, keyValueCh)
+		p.msgChans[i] = make(chan keyValueReq)
+		p.buffers[i] = p.buffer(i)
+		p.wg.Add(1)
+		go p.processMessages(i)
 	}
 
 	return p
 }
 
+// buffer Queue of pending messages of producer i, spilled to disk beyond SpillMemLen if SpillDir is set
+func (p *Producer) buffer(i int) *channel.Buffer[keyValueReq] {
+	if p.config.SpillDir != "" {
+		b, err := channel.NewSpillBuffer(p.msgChans[i], filepath.Join(p.config.SpillDir, strconv.Itoa(i)),
+			p.config.SpillMemLen, channel.WithCodec(marshalReq, unmarshalReq))
+		if err == nil {
+			return b
+		}
+		p.logger.ErrorWithFields("sns Producer.buffer spill", log.Fields{"dir": p.config.SpillDir, "err": err.Error()})
+	}
+	return channel.NewNoBlock[keyValueReq](p.msgChans[i])
+}
+
+// fallback Replace the spill buffer of producer i stopped on a disk error by an in-memory buffer holding
+// its undelivered messages, messages left on disk are sent after restart
+func (p *Producer) fallback(i int) *channel.Buffer[keyValueReq] {
+	failed := p.buffers[i]
+	p.logger.ErrorWithFields("sns Producer.processMessages spill", log.Fields{"dir": p.config.SpillDir, "err": fmt.Sprint(failed.Err())})
+	b := channel.NewNoBlock[keyValueReq](p.msgChans[i])
+	b.Requeue(failed.Stop()...)
+	p.buffers[i] = b
+	return b
+}
+
 func (p *Producer) GetUserShard(key string) int {
 	md5str1 := fmt.Sprintf("%x", md5.Sum([]byte(key)))
 	ret, _ := strconv.ParseInt(md5str1[22:], 16, 0)
@@ -72,99 +131,229 @@ func (p *Producer) GetUserShard(key string) int {
 
 func (p *Producer) Pub(key, value string) error {
 	shard := p.GetUserShard(key)
+	if p.config.SpillDir != "" {
+		// Spilled messages are resent until they succeed or are given up on, possibly after a restart, nobody waits for them
+		return p.send(shard, keyValueReq{
+			key:   key,
+			value: value,
+		})
+	}
 	errCh := make(chan error)
-	p.msgChans[shard] <- keyValueReq{
+	if err := p.send(shard, keyValueReq{
 		key:   key,
 		value: value,
 		errCh: errCh,
+	}); err != nil {
+		return err
 	}
 	return <-errCh
 }
 
-func (p *Producer) processMessages(i int, keyValueCh chan interface{}) {
+// send Queue msg on producer shard, fails with ErrProducerClosed after Shutdown
+func (p *Producer) send(shard int, msg keyValueReq) error {
+	select {
+	case p.msgChans[shard] <- msg:
+		return nil
+	case <-p.stopCh:
+		return ErrProducerClosed
+	}
+}
+
+// Shutdown Stop publishing and wait until messages being sent are done or ctx is done. With SpillDir
+// pending messages are persisted and sent after restart, otherwise their Pub returns ErrProducerClosed.
+// Can be called again after ctx is done to finish the shutdown
+func (p *Producer) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		close(p.stopCh)
+	})
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var err error
+	lost := 0
+	for _, b := range p.buffers {
+		for _, msg := range b.Stop() {
+			if msg.errCh != nil {
+				msg.errCh <- ErrProducerClosed
+			} else {
+				// Spilled message which could not be persisted
+				lost++
+			}
+		}
+		if bErr := b.Err(); bErr != nil && err == nil {
+			err = errors.Wrap(bErr, "sns Producer.Shutdown persist")
+		}
+	}
+	if lost > 0 && err == nil {
+		err = errors.Errorf("sns Producer.Shutdown %d pending messages lost", lost)
+	}
+	return err
+}
+
+// Close Shutdown without deadline
+func (p *Producer) Close() error {
+	return p.Shutdown(context.Background())
+}
+
+// retryDelay Delay before resending a message after attempt failed sends
+func retryDelay(attempt int) time.Duration {
+	delay := retryInitialDelay
+	for n := 1; n < attempt && delay < retryMaxDelay; n++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// permanent Whether a send failed for a reason resending does not fix, like an invalid message,
+// a missing topic or a denied permission. Throttling and expired credentials are worth retrying
+func permanent(err error) bool {
+	reqErr, ok := errors.Cause(err).(awserr.RequestFailure)
+	if !ok {
+		return false
+	}
+	switch reqErr.Code() {
+	case sns.ErrCodeThrottledException, sns.ErrCodeKMSThrottlingException:
+		return false
+	}
+	if request.IsErrorRetryable(reqErr) || request.IsErrorThrottle(reqErr) || request.IsErrorExpiredCreds(reqErr) {
+		return false
+	}
+	status := reqErr.StatusCode()
+	return status >= http.StatusBadRequest && status < http.StatusInternalServerError && status != http.StatusTooManyRequests
+}
+
+func (p *Producer) processMessages(i int) {
+	defer p.wg.Done()
 	p.logger.Infof("sns Producer.processMessages start. task_id:%d", i)
 
 	var cfgSession *session.Session
 	var service *sns.SNS
-	var err error
 	var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
-	for {
-		msg := <-keyValueCh
-
-		func() {
-			defer func() { msg.(keyValueReq).errCh <- err }()
-
-			if cfgSession == nil {
-				cfg := new(aws.Config)
-				if p.config.APIKey != "" && p.config.SecretKey != "" {
-					cfg = &aws.Config{
-						Region: aws.String(p.config.Region),
-						Credentials: credentials.NewStaticCredentials(
-							p.config.APIKey, p.config.SecretKey, ""),
-					}
-				} else {
-					cfg = &aws.Config{
-						Region: aws.String(p.config.Region),
-					}
+	publish := func(msg keyValueReq) (err error) {
+		if cfgSession == nil {
+			cfg := new(aws.Config)
+			if p.config.APIKey != "" && p.config.SecretKey != "" {
+				cfg = &aws.Config{
+					Region: aws.String(p.config.Region),
+					Credentials: credentials.NewStaticCredentials(
+						p.config.APIKey, p.config.SecretKey, ""),
 				}
-
-				cfgSession, err = session.NewSession(cfg)
-				if err != nil {
-					err = errors.Wrap(err, "sns Producer.processMessages session")
-					p.logger.ErrorWithFields("sns Producer.processMessages session", log.Fields{"err": err.Error()})
-					return
+			} else {
+				cfg = &aws.Config{
+					Region: aws.String(p.config.Region),
 				}
 			}
 
-			if service == nil {
-				service = sns.New(cfgSession)
-			}
-
-			var msgData []byte
-			msgInfo := &struct {
-				MsgID            string `json:"msgId"`
-				BornTimestamp    int64  `json:"bornTimestamp"`
-				ReceiveTimestamp int64  `json:"receiveTimestamp"`
-				Data             string `json:"data"`
-			}{
-				MsgID:         fmt.Sprint(time.Now().UnixNano()),
-				BornTimestamp: time.Now().UnixNano() / int64(time.Millisecond),
-				Data:          msg.(keyValueReq).value,
-			}
-			msgData, err = json.Marshal(msgInfo)
+			cfgSession, err = session.NewSession(cfg)
 			if err != nil {
-				err = errors.Wrap(err, "sns Producer.processMessages marshal")
-				p.logger.ErrorWithFields("sns Producer.processMessages marshal", log.Fields{"err": err.Error()})
+				err = errors.Wrap(err, "sns Producer.processMessages session")
+				p.logger.ErrorWithFields("sns Producer.processMessages session", log.Fields{"err": err.Error()})
 				return
 			}
+		}
 
-			tp := time.Now()
+		if service == nil {
+			service = sns.New(cfgSession)
+		}
 
-			const waitSeconds = 3
-			ctx, cancel := context.WithTimeout(context.Background(), waitSeconds*time.Second)
-			defer cancel()
-			input := &sns.PublishInput{
-				Message:  aws.String(string(msgData)),
-				TopicArn: aws.String(p.config.ARN),
-			}
-			if p.isFifo {
-				msgKey := msg.(keyValueReq).key
-				input.MessageGroupId = aws.String(msgKey)
+		var msgData []byte
+		msgInfo := &struct {
+			MsgID            string `json:"msgId"`
+			BornTimestamp    int64  `json:"bornTimestamp"`
+			ReceiveTimestamp int64  `json:"receiveTimestamp"`
+			Data             string `json:"data"`
+		}{
+			MsgID:         fmt.Sprint(time.Now().UnixNano()),
+			BornTimestamp: time.Now().UnixNano() / int64(time.Millisecond),
+			Data:          msg.value,
+		}
+		msgData, err = json.Marshal(msgInfo)
+		if err != nil {
+			err = errors.Wrap(err, "sns Producer.processMessages marshal")
+			p.logger.ErrorWithFields("sns Producer.processMessages marshal", log.Fields{"err": err.Error()})
+			return
+		}
+
+		tp := time.Now()
+
+		const waitSeconds = 3
+		ctx, cancel := context.WithTimeout(context.Background(), waitSeconds*time.Second)
+		defer cancel()
+		input := &sns.PublishInput{
+			Message:  aws.String(string(msgData)),
+			TopicArn: aws.String(p.config.ARN),
+		}
+		if p.isFifo {
+			msgKey := msg.key
+			input.MessageGroupId = aws.String(msgKey)
+		}
+		_, err = service.PublishWithContext(ctx, input)
+		if err != nil {
+			err = errors.Wrap(err, "sns Producer.processMessages send")
+			p.logger.ErrorWithFields("sns Producer.processMessages send", log.Fields{"snsArn": p.config.ARN, "err": err.Error()})
+			return
+		}
+		// fmt.Println("Message ID:", *result.MessageId)
+
+		cost := time.Since(tp).Milliseconds()
+		if cost > TimeoutMS {
+			p.logger.ErrorWithFields("sqs SNS.processMessages handle msg cost.", log.Fields{"sqsArn": p.config.ARN, "cost": cost})
+		}
+		// p.logger.Infof("sqs Producer.processMessages pub end. msg:%s \n", string(msgData))
+		return
+	}
+
+	b := p.buffers[i]
+	for {
+		var msg keyValueReq
+		var ok bool
+		select {
+		case msg, ok = <-b.Out():
+		case <-p.stopCh:
+			return
+		}
+		if !ok {
+			// Spill buffer stopped on a disk error, keep publishing from memory
+			b = p.fallback(i)
+			continue
+		}
+
+		err := publish(msg)
+		if msg.errCh != nil {
+			// The caller decides whether to publish again
+			msg.errCh <- err
+			continue
+		}
+
+		// Spilled message is resent until it succeeds, later messages of the producer wait behind it.
+		// Messages rejected by SNS or failing SpillMaxAttempts times are dropped
+		for attempt := 1; err != nil; attempt++ {
+			if permanent(err) || (p.config.SpillMaxAttempts > 0 && attempt >= p.config.SpillMaxAttempts) {
+				p.logger.ErrorWithFields("sns Producer.processMessages drop", log.Fields{"key": msg.key, "value": msg.value, "attempts": attempt, "err": err.Error()})
+				break
 			}
-			_, err := service.PublishWithContext(ctx, input)
-			if err != nil {
-				err = errors.Wrap(err, "sns Producer.processMessages send")
-				p.logger.ErrorWithFields("sns Producer.processMessages send", log.Fields{"snsArn": p.config.ARN, "err": err.Error()})
+			timer := time.NewTimer(retryDelay(attempt))
+			select {
+			case <-timer.C:
+			case <-p.stopCh:
+				// Back to the front of the buffer, persisted on Shutdown
+				timer.Stop()
+				b.Requeue(msg)
 				return
 			}
-			// fmt.Println("Message ID:", *result.MessageId)
-
-			cost := time.Since(tp).Milliseconds()
-			if cost > TimeoutMS {
-				p.logger.ErrorWithFields("sqs SNS.processMessages handle msg cost.", log.Fields{"sqsArn": p.config.ARN, "cost": cost})
-			}
-			// p.logger.Infof("sqs Producer.processMessages pub end. msg:%s \n", string(msgData))
-		}()
+			err = publish(msg)
+		}
 	}
 }
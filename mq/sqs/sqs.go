@@ -30,14 +30,17 @@ type SQS struct {
 
 // SQSConfig AWS SQS related configuration
 type SQSConfig struct {
-	ARN            string  `mapstructure:"arn" json:"arn"`
-	Region         string  `mapstructure:"region" json:"region"`
-	APIKey         string  `mapstructure:"api_key" json:"api_key"`
-	SecretKey      string  `mapstructure:"secret_key" json:"secret_key"`
-	QueueUrl       string  `mapstructure:"queue_url" json:"queue_url"`
-	MessageGroupId *string `mapstructure:"message_group_id" json:"message_group_id"`
-	ConsumerCnt    int     `mapstructure:"consumer_cnt" json:"consumer_cnt"`
-	ProducerCnt    int     `mapstructure:"producer_cnt" json:"producer_cnt"`
+	ARN              string  `mapstructure:"arn" json:"arn"`
+	Region           string  `mapstructure:"region" json:"region"`
+	APIKey           string  `mapstructure:"api_key" json:"api_key"`
+	SecretKey        string  `mapstructure:"secret_key" json:"secret_key"`
+	QueueUrl         string  `mapstructure:"queue_url" json:"queue_url"`
+	MessageGroupId   *string `mapstructure:"message_group_id" json:"message_group_id"`
+	ConsumerCnt      int     `mapstructure:"consumer_cnt" json:"consumer_cnt"`
+	ProducerCnt      int     `mapstructure:"producer_cnt" json:"producer_cnt"`
+	SpillDir         string  `mapstructure:"spill_dir" json:"spill_dir"`                   // Directory pending messages are spilled to, Pub does not wait for the send result and failed sends are retried if set
+	SpillMemLen      int     `mapstructure:"spill_mem_len" json:"spill_mem_len"`           // Pending messages kept in memory per producer before spilling
+	SpillMaxAttempts int     `mapstructure:"spill_max_attempts" json:"spill_max_attempts"` // Sends of a spilled message before it is dropped, unlimited if 0. Messages rejected by SQS are dropped right away
 }
 
 // Process sns->sqs messages